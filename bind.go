@@ -0,0 +1,121 @@
+// Copyright 2015 Alex Browne.  All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package forms
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Bind sets the fields of the struct pointed to by dst to the corresponding
+// values in d. The key for each field is taken from its `form` struct tag, or
+// the lowercased field name if there is no tag. A tag of "-" means the field
+// is skipped. Supported field types are string, int, int64, float64, bool, and
+// []string, as well as named types whose underlying type is one of these (e.g.
+// type Role string). Fields whose key does not exist in d are left unchanged.
+//
+// Bind returns an error if dst is not a non-nil pointer to a struct, if a field
+// has an unsupported type, or if a value cannot be converted to the type of its
+// field. It never panics because of malformed input.
+func (d Data) Bind(dst interface{}) error {
+	ptr := reflect.ValueOf(dst)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() || ptr.Elem().Kind() != reflect.Struct {
+		return errors.New("forms: Bind requires a non-nil pointer to a struct")
+	}
+	structVal := ptr.Elem()
+	structType := structVal.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.PkgPath != "" {
+			// unexported field
+			continue
+		}
+		key := field.Tag.Get("form")
+		if key == "-" {
+			continue
+		} else if key == "" {
+			key = strings.ToLower(field.Name)
+		}
+		if !d.KeyExists(key) {
+			continue
+		}
+		if err := d.bindField(structVal.Field(i), key); err != nil {
+			return fmt.Errorf("forms: could not bind %s to field %s: %s", key, field.Name, err)
+		}
+	}
+	return nil
+}
+
+// BindWithDefaults is like Bind, but first copies defaults into the struct
+// pointed to by dst. defaults must be a struct, or a pointer to a struct, of
+// the same type as *dst. Since Bind leaves fields whose key does not exist in
+// d unchanged, the result holds the submitted values for keys which were
+// present and the defaults for all other fields. This is useful for forms
+// which edit existing settings, where omitted fields should keep their
+// current values. The copy is shallow, so slice fields share their backing
+// array with defaults until they are overwritten.
+func (d Data) BindWithDefaults(dst interface{}, defaults interface{}) error {
+	ptr := reflect.ValueOf(dst)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() || ptr.Elem().Kind() != reflect.Struct {
+		return errors.New("forms: BindWithDefaults requires a non-nil pointer to a struct")
+	}
+	defaultsVal := reflect.ValueOf(defaults)
+	if defaultsVal.Kind() == reflect.Ptr && !defaultsVal.IsNil() {
+		defaultsVal = defaultsVal.Elem()
+	}
+	if !defaultsVal.IsValid() || defaultsVal.Type() != ptr.Elem().Type() {
+		return fmt.Errorf("forms: BindWithDefaults requires defaults of type %s but got %T", ptr.Elem().Type(), defaults)
+	}
+	ptr.Elem().Set(defaultsVal)
+	return d.Bind(dst)
+}
+
+// bindField sets fieldVal to the value(s) associated with key, converting
+// them to the type of fieldVal. It switches on the kind of fieldVal rather
+// than its type so that named types are supported.
+func (d Data) bindField(fieldVal reflect.Value, key string) error {
+	switch fieldVal.Kind() {
+	case reflect.String:
+		fieldVal.SetString(d.Get(key))
+	case reflect.Int:
+		result, err := d.GetIntErr(key)
+		if err != nil {
+			return err
+		}
+		fieldVal.SetInt(int64(result))
+	case reflect.Int64:
+		result, err := d.GetInt64Err(key)
+		if err != nil {
+			return err
+		}
+		fieldVal.SetInt(result)
+	case reflect.Float64:
+		result, err := d.GetFloatErr(key)
+		if err != nil {
+			return err
+		}
+		fieldVal.SetFloat(result)
+	case reflect.Bool:
+		result, err := d.GetBoolErr(key)
+		if err != nil {
+			return err
+		}
+		fieldVal.SetBool(result)
+	case reflect.Slice:
+		if fieldVal.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported field type %s", fieldVal.Type())
+		}
+		vals := reflect.MakeSlice(fieldVal.Type(), len(d.Values[key]), len(d.Values[key]))
+		for i, val := range d.Values[key] {
+			vals.Index(i).SetString(val)
+		}
+		fieldVal.Set(vals)
+	default:
+		return fmt.Errorf("unsupported field type %s", fieldVal.Type())
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+// Copyright 2015 Alex Browne.  All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package forms
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type bindRole string
+
+type bindLevel int
+
+type bindUser struct {
+	Name     string     `form:"username"`
+	Age      int        `form:"age"`
+	ID       int64      `form:"id"`
+	Rating   float64    `form:"rating"`
+	Admin    bool       `form:"admin"`
+	Tags     []string   `form:"tag"`
+	Role     bindRole   `form:"role"`
+	Level    bindLevel  `form:"level"`
+	Roles    []bindRole `form:"extraRole"`
+	Email    string
+	Ignored  string `form:"-"`
+	internal string
+}
+
+func TestBind(t *testing.T) {
+	data := newData()
+	data.Add("username", "bob")
+	data.Add("age", "25")
+	data.Add("id", "9223372036854775807")
+	data.Add("rating", "4.5")
+	data.Add("admin", "true")
+	data.Add("tag", "a")
+	data.Add("tag", "b")
+	data.Add("email", "bob@example.com")
+	data.Add("role", "admin")
+	data.Add("level", "3")
+	data.Add("extraRole", "editor")
+	data.Add("Ignored", "oops")
+	data.Add("internal", "oops")
+
+	got := bindUser{}
+	if err := data.Bind(&got); err != nil {
+		t.Fatal(err)
+	}
+	expected := bindUser{
+		Name:   "bob",
+		Age:    25,
+		ID:     9223372036854775807,
+		Rating: 4.5,
+		Admin:  true,
+		Tags:   []string{"a", "b"},
+		Role:   "admin",
+		Level:  3,
+		Roles:  []bindRole{"editor"},
+		Email:  "bob@example.com",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Result of Bind was incorrect. Expected %+v, but got %+v.\n", expected, got)
+	}
+
+	// Mutating the bound slice should not affect data
+	got.Tags[0] = "z"
+	if data.Get("tag") != "a" {
+		t.Errorf("Expected Bind to copy slices but data was mutated: %v", data.Values["tag"])
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	data := newData()
+	data.Add("age", "twenty-five")
+	err := data.Bind(&bindUser{})
+	if err == nil {
+		t.Fatal("Expected an error for an unparseable int but got none.")
+	}
+	for _, expected := range []string{"age", "Age", "twenty-five"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf(`Expected error to contain "%s" but got: %s`, expected, err)
+		}
+	}
+
+	if err := data.Bind(bindUser{}); err == nil {
+		t.Error("Expected an error when binding to a non-pointer but got none.")
+	}
+	if err := data.Bind((*bindUser)(nil)); err == nil {
+		t.Error("Expected an error when binding to a nil pointer but got none.")
+	}
+
+	unsupported := struct {
+		Count uint8 `form:"count"`
+	}{}
+	data.Add("count", "1")
+	if err := data.Bind(&unsupported); err == nil {
+		t.Error("Expected an error for an unsupported field type but got none.")
+	}
+	unsupportedSlice := struct {
+		Counts []int `form:"count"`
+	}{}
+	if err := data.Bind(&unsupportedSlice); err == nil {
+		t.Error("Expected an error for an unsupported slice type but got none.")
+	}
+}
+
+func TestBindWithDefaults(t *testing.T) {
+	data := newData()
+	data.Add("username", "bob")
+	data.Add("admin", "false")
+
+	defaults := bindUser{
+		Name:   "anonymous",
+		Age:    30,
+		Rating: 3.5,
+		Admin:  true,
+		Tags:   []string{"default"},
+		Email:  "old@example.com",
+	}
+	got := bindUser{Age: 99, Ignored: "stale"}
+	if err := data.BindWithDefaults(&got, defaults); err != nil {
+		t.Fatal(err)
+	}
+	expected := defaults
+	expected.Name = "bob"
+	expected.Admin = false
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Result of BindWithDefaults was incorrect. Expected %+v, but got %+v.\n", expected, got)
+	}
+
+	// defaults may also be a pointer
+	got = bindUser{}
+	if err := data.BindWithDefaults(&got, &defaults); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Result of BindWithDefaults with a pointer was incorrect. Expected %+v, but got %+v.\n", expected, got)
+	}
+
+	if err := data.BindWithDefaults(&got, struct{ Name string }{}); err == nil {
+		t.Error("Expected an error for defaults of a different type but got none.")
+	}
+	if err := data.BindWithDefaults(&got, nil); err == nil {
+		t.Error("Expected an error for nil defaults but got none.")
+	}
+	if err := data.BindWithDefaults(got, defaults); err == nil {
+		t.Error("Expected an error when binding to a non-pointer but got none.")
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2015 Alex Browne.  All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package forms
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+	"unicode/utf8"
+)
+
+// decoder converts a string in some character set to UTF-8.
+type decoder func(s string) string
+
+// charsetDecoder returns a decoder for the charset parameter of contentType.
+// If contentType does not specify a charset, or the charset is UTF-8 or
+// US-ASCII, the returned decoder does nothing. ISO-8859-1 (Latin-1) is
+// transcoded to UTF-8. Any other charset results in an error, since decoding
+// it as UTF-8 would silently corrupt the data.
+func charsetDecoder(contentType string) (decoder, error) {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		// Content-Type is matched leniently elsewhere, so a malformed
+		// header should not cause an error here.
+		return noopDecoder, nil
+	}
+	charset := strings.ToLower(params["charset"])
+	switch charset {
+	case "", "utf-8", "utf8", "us-ascii", "ascii":
+		return noopDecoder, nil
+	case "iso-8859-1", "iso8859-1", "latin1", "latin-1", "l1":
+		return decodeLatin1, nil
+	}
+	return nil, fmt.Errorf("forms: unsupported charset %q", charset)
+}
+
+func noopDecoder(s string) string {
+	return s
+}
+
+// decodeLatin1 converts s from ISO-8859-1 to UTF-8. Every byte in ISO-8859-1
+// corresponds directly to the unicode code point with the same value.
+func decodeLatin1(s string) string {
+	buf := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] < utf8.RuneSelf {
+			buf = append(buf, s[i])
+		} else {
+			buf = append(buf, string(rune(s[i]))...)
+		}
+	}
+	return string(buf)
+}
@@ -0,0 +1,71 @@
+// Copyright 2015 Alex Browne.  All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package forms
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestParseLatin1(t *testing.T) {
+	// "café" and "Zoë" encoded in ISO-8859-1 and then url encoded
+	body := "drink=caf%E9&name=Zo%EB"
+	req, err := http.NewRequest("POST", "/", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=ISO-8859-1")
+	d, err := Parse(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Get("drink"); got != "café" {
+		t.Errorf(`Expected drink to be "café" but got "%s"`, got)
+	}
+	if got := d.Get("name"); got != "Zoë" {
+		t.Errorf(`Expected name to be "Zoë" but got "%s"`, got)
+	}
+
+	req, err = http.NewRequest("POST", "/", strings.NewReader("caf\xe9"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=latin1")
+	d, err = Parse(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Get(DefaultBodyKey); got != "café" {
+		t.Errorf(`Expected body to be "café" but got "%s"`, got)
+	}
+}
+
+func TestParseUnsupportedCharset(t *testing.T) {
+	req, err := http.NewRequest("POST", "/", strings.NewReader("name=bob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=Shift_JIS")
+	if _, err := Parse(req); err == nil {
+		t.Error("Expected an error for an unsupported charset but got none.")
+	} else if !strings.Contains(err.Error(), "shift_jis") {
+		t.Errorf("Expected error to name the charset but got: %s", err)
+	}
+
+	// UTF-8 should be accepted and left alone
+	req, err = http.NewRequest("POST", "/", strings.NewReader("name=Zo%C3%AB"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=UTF-8")
+	d, err := Parse(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Get("name"); got != "Zoë" {
+		t.Errorf(`Expected name to be "Zoë" but got "%s"`, got)
+	}
+}
@@ -5,18 +5,222 @@
 package forms
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"image/color"
+	"io"
 	"io/ioutil"
+	"math/big"
+	"mime"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrKeyNotFound is returned by methods which require a value for a given key
+// when there is no value (or only an empty value) associated with that key.
+var ErrKeyNotFound = errors.New("forms: key not found")
+
+// ErrBodyTooLarge is returned when parsing a urlencoded or text/plain request
+// whose body is larger than the limit given by Options.MaxBodySize.
+var ErrBodyTooLarge = errors.New("forms: request body too large")
+
+// ErrFileTooLarge is returned by ReadFile when a file is larger than the
+// given limit.
+var ErrFileTooLarge = errors.New("forms: file too large")
+
+// ErrMultipart, ErrURLEncoded, and ErrJSON identify errors which occurred
+// while parsing a multipart, urlencoded, or json request body respectively.
+// Errors returned by Parse wrap the underlying error, so they can be checked
+// with errors.Is, e.g. errors.Is(err, forms.ErrMultipart).
+var (
+	ErrMultipart  = errors.New("forms: could not parse multipart body")
+	ErrURLEncoded = errors.New("forms: could not parse urlencoded body")
+	ErrJSON       = errors.New("forms: could not parse json body")
+)
+
+// parseError wraps an error which occurred while parsing a request body. It
+// matches both kind and the underlying error with errors.Is.
+type parseError struct {
+	kind error
+	err  error
+}
+
+func (e *parseError) Error() string {
+	return fmt.Sprintf("%s: %s", e.kind, e.err)
+}
+
+func (e *parseError) Unwrap() error {
+	return e.err
+}
+
+func (e *parseError) Is(target error) bool {
+	return target == e.kind
+}
+
+// DefaultBodyKey is the key under which the body of a text/plain request is
+// stored if Options.BodyKey is empty.
+const DefaultBodyKey = "_body"
+
+// ErrTooManyKeys and ErrTooManyValues are returned when parsing a request
+// which has more keys, or more values for a single key, than allowed by
+// Options.MaxKeys and Options.MaxValuesPerKey.
+var (
+	ErrTooManyKeys   = errors.New("forms: too many keys")
+	ErrTooManyValues = errors.New("forms: too many values for key")
+)
+
+// DefaultMaxKeys and DefaultMaxValuesPerKey are the default limits on the
+// number of keys and the number of values per key used by the Parse function.
+const (
+	DefaultMaxKeys         = 1000
+	DefaultMaxValuesPerKey = 1000
 )
 
 // DefaultMaxFormSize is the default maximum form size (in bytes) used by the Parse function.
-const DefaultMaxFormSize = 500000
+// It matches the default used by http.Request.FormFile.
+const DefaultMaxFormSize = 32 << 20
+
+// DefaultMaxBodySize is the default maximum size (in bytes) of a urlencoded or
+// text/plain request body used by the Parse function. It matches the limit
+// used by http.Request.ParseForm.
+const DefaultMaxBodySize = 10 << 20
+
+// Options configures the behavior of ParseWithOptions. The zero value is
+// valid and results in the same behavior as Parse.
+type Options struct {
+	// MaxMemory is the maximum number of bytes of a multipart form which
+	// will be stored in memory. The remainder of any files will be stored
+	// on disk in temporary files. If MaxMemory is 0, DefaultMaxFormSize
+	// is used instead.
+	MaxMemory int64
+	// MaxBodySize is the maximum size of a urlencoded or text/plain body,
+	// or of any body if KeepRawBody is set, since these are read into
+	// memory in full. If the body is larger,
+	// ErrBodyTooLarge is returned. If MaxBodySize is 0,
+	// DefaultMaxBodySize is used instead.
+	MaxBodySize int64
+	// MaxDecompressedSize is the maximum size of a body with a
+	// Content-Encoding of gzip or deflate after it has been decompressed.
+	// If the decompressed body is larger, ErrBodyTooLarge is returned.
+	// This guards against small requests which decompress to a huge size.
+	// If MaxDecompressedSize is 0, DefaultMaxFormSize is used instead. A
+	// negative value means there is no limit.
+	MaxDecompressedSize int64
+	// TrimSpace causes leading and trailing whitespace to be removed from
+	// every value as it is added to Data, regardless of whether it came
+	// from the request body or the url query.
+	TrimSpace bool
+	// Normalize, if non-nil, is called on every value as it is added to
+	// Data, regardless of where the value came from. It can be used to
+	// e.g. collapse internal whitespace. If TrimSpace is also set, values
+	// are trimmed before they are passed to Normalize.
+	Normalize func(string) string
+	// BodyKey is the key under which the entire body of a text/plain
+	// request is stored. If BodyKey is empty, DefaultBodyKey is used.
+	// The body is limited to MaxBodySize bytes.
+	BodyKey string
+	// MaxKeys is the maximum number of distinct keys, and MaxValuesPerKey
+	// is the maximum number of values for any single key. If either limit
+	// is exceeded, parsing stops and ErrTooManyKeys or ErrTooManyValues is
+	// returned. The limits are checked as the body is read, so the rest
+	// of the body is not read once they are exceeded. These guard against
+	// requests with an excessive number of parameters. If 0, DefaultMaxKeys and DefaultMaxValuesPerKey are used
+	// instead. A negative value means there is no limit.
+	MaxKeys         int
+	MaxValuesPerKey int
+	// Defaults holds values which are added to Data after parsing for any
+	// key which was not present in the request. A key which was present
+	// with an empty value (e.g. "name=") is not considered absent and does
+	// not get a default. Defaults are not affected by TrimSpace, Normalize,
+	// or any limits.
+	Defaults map[string]string
+	// ExpandNested causes keys which use bracket notation, such as
+	// "user[name]" or "items[0][price]", to be expanded into a tree of
+	// nested maps after parsing. The tree is available via Data.Nested and
+	// Data.GetNested. The original flat keys are kept as well.
+	ExpandNested bool
+	// KeepRawBody causes the exact bytes of the request body to be kept
+	// so that they are available via Data.RawBody after parsing, e.g. to
+	// verify the signature of a webhook. The body is read into memory in
+	// full before it is decompressed, so it is limited to MaxBodySize
+	// bytes regardless of its Content-Type. If it is larger,
+	// ErrBodyTooLarge is returned.
+	KeepRawBody bool
+}
+
+// withDefaults returns a copy of opts with any zero values replaced by
+// their defaults.
+func (opts Options) withDefaults() Options {
+	if opts.MaxMemory == 0 {
+		opts.MaxMemory = DefaultMaxFormSize
+	}
+	if opts.MaxBodySize == 0 {
+		opts.MaxBodySize = DefaultMaxBodySize
+	}
+	if opts.MaxDecompressedSize == 0 {
+		opts.MaxDecompressedSize = DefaultMaxFormSize
+	}
+	if opts.BodyKey == "" {
+		opts.BodyKey = DefaultBodyKey
+	}
+	if opts.MaxKeys == 0 {
+		opts.MaxKeys = DefaultMaxKeys
+	}
+	if opts.MaxValuesPerKey == 0 {
+		opts.MaxValuesPerKey = DefaultMaxValuesPerKey
+	}
+	return opts
+}
+
+// add adds val to data under key after transforming it according to opts.
+// It returns an error if doing so would exceed the limits in opts.
+func (opts Options) add(data *Data, key string, val string) error {
+	if opts.MaxKeys >= 0 && !data.KeyExists(key) && len(data.Values) >= opts.MaxKeys {
+		return ErrTooManyKeys
+	}
+	if opts.MaxValuesPerKey >= 0 && len(data.Values[key]) >= opts.MaxValuesPerKey {
+		return ErrTooManyValues
+	}
+	data.Add(key, opts.value(val))
+	return nil
+}
+
+// finish applies the parts of opts which take effect once data has been
+// fully parsed, i.e. Defaults and ExpandNested.
+func (opts Options) finish(data *Data) {
+	for key, val := range opts.Defaults {
+		if !data.KeyExists(key) {
+			data.Add(key, val)
+		}
+	}
+	if opts.ExpandNested {
+		data.nested = expandNested(data.Values)
+	}
+}
+
+// value returns val transformed according to opts.
+func (opts Options) value(val string) string {
+	if opts.TrimSpace {
+		val = strings.TrimSpace(val)
+	}
+	if opts.Normalize != nil {
+		val = opts.Normalize(val)
+	}
+	return val
+}
 
 // Data holds data obtained from the request body and url query parameters.
 // Because Data is built from multiple sources, sometimes there will be more
@@ -24,82 +228,358 @@ const DefaultMaxFormSize = 500000
 // the first element for a given key or access the Values and Files properties directly
 // to access additional elements for a given key. You can also use helper methods to convert
 // the first value for a given key to a different type (e.g. bool or int).
+// Data is not safe for concurrent use; see SyncData.
 type Data struct {
 	// Values holds any basic key-value string data
 	// This includes all fields from a json body or
 	// urlencoded form, and the form fields only (not
-	// files) from a multipart form
+	// files) from a multipart form. If a multipart form
+	// uses the same name for both text fields and files,
+	// the text values are held here and the file in Files.
 	Values url.Values
 	// Files holds files from a multipart form only.
 	// For any other type of request, it will always
-	// be empty. Files only supports one file per key,
-	// since this is by far the most common use. If you
-	// need to have more than one file per key, parse the
-	// files manually using req.MultipartForm.File.
+	// be empty. Files only holds the first file for each
+	// key, since this is by far the most common use. If
+	// you need every file for a key, use FileHeaders.
 	Files map[string]*multipart.FileHeader
+	// fileHeaders holds every file for each key in a
+	// multipart form. Files remains the source of truth
+	// for which keys have a file (see FileHeaders).
+	fileHeaders map[string][]*multipart.FileHeader
 	// jsonBody holds the original body of the request.
 	// Only available for json requests.
 	jsonBody []byte
+	// nested holds the values expanded by bracket notation.
+	// Only available if Options.ExpandNested was set.
+	nested map[string]interface{}
+	// rawBody holds the exact bytes of the request body.
+	// Only available if Options.KeepRawBody was set.
+	rawBody []byte
+	// contentLength holds the length of the request body
+	// as given by req.ContentLength, or -1 if unknown.
+	contentLength int64
 }
 
 func newData() *Data {
 	return &Data{
-		Values: url.Values{},
-		Files:  map[string]*multipart.FileHeader{},
+		Values:        url.Values{},
+		Files:         map[string]*multipart.FileHeader{},
+		contentLength: -1,
 	}
 }
 
-// ParseMax parses the request body and url query parameters into
-// Data. The content in the body of the request has a higher priority,
-// will be added to Data first, and will be the result of any operation
-// which gets the first element for a given key (e.g. Get, GetInt, or GetBool).
-func ParseMax(req *http.Request, max int64) (*Data, error) {
+// ParseWithOptions parses the request body and url query parameters into
+// Data, using opts to configure how the request is parsed. The content in
+// the body of the request has a higher priority, will be added to Data first,
+// and will be the result of any operation which gets the first element for a
+// given key (e.g. Get, GetInt, or GetBool).
+func ParseWithOptions(req *http.Request, opts Options) (*Data, error) {
+	opts = opts.withDefaults()
 	data := newData()
+	if err := parseBody(req, data, opts); err != nil {
+		return nil, err
+	}
+	if err := parseQuery(req, data, opts); err != nil {
+		return nil, err
+	}
+	opts.finish(data)
+	return data, nil
+}
+
+// ParseBody is like Parse, but only parses the request body and ignores any
+// url query parameters.
+func ParseBody(req *http.Request) (*Data, error) {
+	data := newData()
+	if err := parseBody(req, data, Options{}.withDefaults()); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ParseQuery is like Parse, but only parses the url query parameters. It never
+// reads the request body, so it cannot fail. Since the url is already held in
+// memory, no limits are placed on the number of keys or values.
+func ParseQuery(req *http.Request) *Data {
+	data := newData()
+	opts := Options{MaxKeys: -1, MaxValuesPerKey: -1}.withDefaults()
+	// parseQuery can only fail if a limit is exceeded
+	_ = parseQuery(req, data, opts)
+	return data
+}
+
+// parseBody parses the request body into data according to its Content-Type.
+// Requests with an unsupported Content-Type are ignored. For urlencoded and
+// text/plain bodies, the charset parameter of the Content-Type is respected
+// (see charsetDecoder). Bodies with a Content-Encoding of gzip or deflate are
+// decompressed as they are read, and the decompressed body is limited to
+// MaxDecompressedSize bytes (see decompressBody). The headers and
+// ContentLength of req are left as they are.
+func parseBody(req *http.Request, data *Data, opts Options) error {
+	data.contentLength = req.ContentLength
+	var body io.Reader = http.NoBody
+	if req.Body != nil {
+		body = req.Body
+	}
+	if opts.KeepRawBody {
+		raw, err := readAllMax(body, opts.MaxBodySize)
+		if err != nil {
+			return err
+		}
+		data.rawBody = raw
+		body = bytes.NewReader(raw)
+	}
+	decompressed, err := decompressBody(body, req.Header.Get("Content-Encoding"), opts.MaxDecompressedSize)
+	if err != nil {
+		return err
+	}
+	defer decompressed.Close()
+	body = decompressed
 	contentType := req.Header.Get("Content-Type")
 	if strings.Contains(contentType, "multipart/form-data") {
-		if err := req.ParseMultipartForm(max); err != nil {
-			return nil, err
+		if req.MultipartForm == nil {
+			// Read the form directly instead of using
+			// req.ParseMultipartForm, which reads from req.Body and so
+			// would not see the decompressed body.
+			_, params, err := mime.ParseMediaType(contentType)
+			if err != nil {
+				return &parseError{kind: ErrMultipart, err: err}
+			}
+			boundary := params["boundary"]
+			if boundary == "" {
+				return &parseError{kind: ErrMultipart, err: http.ErrMissingBoundary}
+			}
+			counter := newPartCounter(body, boundary, opts)
+			form, err := multipart.NewReader(counter, boundary).ReadForm(opts.MaxMemory)
+			if counter.err != nil {
+				return counter.err
+			}
+			if err != nil {
+				return &parseError{kind: ErrMultipart, err: err}
+			}
+			// As with req.ParseMultipartForm, keep the form on req so
+			// that the server removes any temporary files it created.
+			req.MultipartForm = form
 		}
 		for key, vals := range req.MultipartForm.Value {
 			for _, val := range vals {
-				data.Add(key, val)
+				if err := opts.add(data, key, val); err != nil {
+					return err
+				}
 			}
 		}
 		for key, files := range req.MultipartForm.File {
-			if len(files) != 0 {
-				data.AddFile(key, files[0])
+			// As with Merge, a file already in data is kept.
+			if len(files) != 0 && !data.FileExists(key) {
+				data.addFiles(key, files)
 			}
 		}
 	} else if strings.Contains(contentType, "form-urlencoded") {
-		if err := req.ParseForm(); err != nil {
-			return nil, err
+		decode, err := charsetDecoder(contentType)
+		if err != nil {
+			return &parseError{kind: ErrURLEncoded, err: err}
+		}
+		if req.PostForm == nil {
+			// Read the body directly instead of using req.ParseForm,
+			// which ignores the body for methods other than POST, PUT,
+			// and PATCH. Values are added as they are read, so that the
+			// limits are enforced before the whole body has been read.
+			postForm, err := parseURLEncoded(body, opts.MaxBodySize, func(key string, val string) error {
+				return opts.add(data, decode(key), decode(val))
+			})
+			if err != nil {
+				return err
+			}
+			req.PostForm = postForm
+			return nil
 		}
 		for key, vals := range req.PostForm {
 			for _, val := range vals {
-				data.Add(key, val)
+				if err := opts.add(data, decode(key), decode(val)); err != nil {
+					return err
+				}
 			}
 		}
 	} else if strings.Contains(contentType, "application/json") {
-		body, err := ioutil.ReadAll(req.Body)
+		raw, err := parseJSON(data, body, opts)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		data.jsonBody = body
-		if err := parseJSON(data.Values, data.jsonBody); err != nil {
-			return nil, err
+		data.jsonBody = raw
+	} else if strings.Contains(contentType, "text/plain") {
+		decode, err := charsetDecoder(contentType)
+		if err != nil {
+			return err
+		}
+		raw, err := readAllMax(body, opts.MaxBodySize)
+		if err != nil {
+			return err
+		}
+		if err := opts.add(data, opts.BodyKey, decode(string(raw))); err != nil {
+			return err
 		}
 	}
+	return nil
+}
+
+// parseQuery adds the url query parameters for req to data.
+func parseQuery(req *http.Request, data *Data, opts Options) error {
 	for key, vals := range req.URL.Query() {
 		for _, val := range vals {
-			data.Add(key, val)
+			if err := opts.add(data, key, val); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ParseContext is like Parse, but stops reading the request body and returns
+// ctx.Err() if ctx is cancelled or times out before the body has been read.
+// This is useful for abandoning slow uploads. Note that the context is checked
+// between reads, so a single read which blocks indefinitely will not be
+// interrupted. req.Body is restored before ParseContext returns, so any later
+// reads of the body are not affected by ctx.
+func ParseContext(ctx context.Context, req *http.Request) (*Data, error) {
+	return ParseContextWithOptions(ctx, req, Options{})
+}
+
+// ParseContextWithOptions is like ParseContext, but uses opts to configure
+// the behavior of the parser (see ParseWithOptions).
+func ParseContextWithOptions(ctx context.Context, req *http.Request, opts Options) (*Data, error) {
+	if req.Body != nil {
+		body := req.Body
+		req.Body = &contextReader{ctx: ctx, body: body}
+		defer func() { req.Body = body }()
+	}
+	data, err := ParseWithOptions(req, opts)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
 		}
+		return nil, err
 	}
 	return data, nil
 }
 
-// Parse uses the default max form size defined above and calls ParseMax
+// contextReader wraps a request body and returns an error from Read once
+// ctx is done.
+type contextReader struct {
+	ctx  context.Context
+	body io.ReadCloser
+}
+
+func (cr *contextReader) Read(p []byte) (int, error) {
+	select {
+	case <-cr.ctx.Done():
+		return 0, cr.ctx.Err()
+	default:
+		return cr.body.Read(p)
+	}
+}
+
+func (cr *contextReader) Close() error {
+	return cr.body.Close()
+}
+
+// readAllMax reads all of r, returning ErrBodyTooLarge if r holds more than
+// max bytes.
+func readAllMax(r io.Reader, max int64) ([]byte, error) {
+	if r == nil {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > max {
+		return nil, ErrBodyTooLarge
+	}
+	return body, nil
+}
+
+// ParseMax is like Parse but stores at most max bytes of a multipart form
+// in memory. It is equivalent to calling ParseWithOptions with MaxMemory set
+// to max.
+func ParseMax(req *http.Request, max int64) (*Data, error) {
+	return ParseWithOptions(req, Options{MaxMemory: max})
+}
+
+// Parse uses the default max form size defined above and calls ParseWithOptions
 func Parse(req *http.Request) (*Data, error) {
-	return ParseMax(req, DefaultMaxFormSize)
+	return ParseWithOptions(req, Options{})
+}
+
+// AddFromRequest parses req as with Parse, adding the values and files
+// directly to d. As with Merge, values already in d are kept and take
+// precedence for methods which get the first element for a key, and files are
+// only added for keys which do not already have a file. The keys already in d
+// count towards the default limits on the number of keys and values.
+// Afterwards, ContentLength and BindJSON reflect req rather than any earlier
+// request. If req cannot be parsed, d is left unchanged and the error is
+// returned.
+func (d *Data) AddFromRequest(req *http.Request) error {
+	opts := Options{}.withDefaults()
+	state := d.state()
+	if err := parseBody(req, d, opts); err != nil {
+		d.restore(state)
+		return err
+	}
+	if err := parseQuery(req, d, opts); err != nil {
+		d.restore(state)
+		return err
+	}
+	opts.finish(d)
+	return nil
+}
+
+// dataState records enough of a Data to undo a failed parse into it (see
+// AddFromRequest), without copying any of the values themselves.
+type dataState struct {
+	counts        map[string]int
+	files         map[string]bool
+	jsonBody      []byte
+	rawBody       []byte
+	contentLength int64
+}
+
+// state returns the current state of d.
+func (d *Data) state() dataState {
+	state := dataState{
+		counts:        make(map[string]int, len(d.Values)),
+		files:         make(map[string]bool, len(d.Files)),
+		jsonBody:      d.jsonBody,
+		rawBody:       d.rawBody,
+		contentLength: d.contentLength,
+	}
+	for key, vals := range d.Values {
+		state.counts[key] = len(vals)
+	}
+	for key := range d.Files {
+		state.files[key] = true
+	}
+	return state
+}
+
+// restore undoes any changes made to d by parsing since state was recorded.
+// Values are only ever appended and files only added while parsing, so it is
+// enough to drop anything new.
+func (d *Data) restore(state dataState) {
+	for key, vals := range d.Values {
+		if n, found := state.counts[key]; found {
+			d.Values[key] = vals[:n]
+		} else {
+			delete(d.Values, key)
+		}
+	}
+	for key := range d.Files {
+		if !state.files[key] {
+			d.DelFile(key)
+		}
+	}
+	d.jsonBody = state.jsonBody
+	d.rawBody = state.rawBody
+	d.contentLength = state.contentLength
 }
 
 // CreateFromMap returns a Data object with keys and values matching
@@ -112,35 +592,108 @@ func CreateFromMap(m map[string]string) *Data {
 	return data
 }
 
-func parseJSON(values url.Values, body []byte) error {
-	if len(body) == 0 {
+// FromValues returns a Data object which wraps values. values is used
+// directly, not copied, so changes to one will be reflected in the other.
+// Use FromMap if you need a copy.
+func FromValues(values url.Values) *Data {
+	data := newData()
+	if values != nil {
+		data.Values = values
+	}
+	return data
+}
+
+// FromMap returns a Data object with keys and values matching the map.
+// Unlike CreateFromMap, each key may have more than one value. The values
+// are copied, so later changes to m do not affect the result.
+func FromMap(m map[string][]string) *Data {
+	data := newData()
+	for key, vals := range m {
+		data.Values[key] = append([]string(nil), vals...)
+	}
+	return data
+}
+
+// parseJSON adds the top-level fields of the json object in body to data and
+// returns the bytes of body. As with parseURLEncoded, errors returned by
+// opts.add (i.e. exceeded limits) are returned as is, and any other error is
+// wrapped as ErrJSON.
+func parseJSON(data *Data, body io.Reader, opts Options) ([]byte, error) {
+	// Decode the object one field at a time instead of unmarshaling it all
+	// at once, so that the limits are enforced before the whole body has
+	// been read. The body is kept as it is read for BindJSON.
+	raw := &bytes.Buffer{}
+	decoder := json.NewDecoder(io.TeeReader(body, raw))
+	token, err := decoder.Token()
+	if err == io.EOF {
 		// don't attempt to parse empty bodies
-		return nil
+		return raw.Bytes(), nil
+	} else if err != nil {
+		return nil, &parseError{kind: ErrJSON, err: err}
 	}
-	rawData := map[string]interface{}{}
-	if err := json.Unmarshal(body, &rawData); err != nil {
-		return err
+	if token != nil {
+		if delim, ok := token.(json.Delim); !ok || delim != '{' {
+			return nil, &parseError{kind: ErrJSON, err: fmt.Errorf("json: cannot parse %v as an object", token)}
+		}
+		seen := map[string]bool{}
+		for decoder.More() {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, &parseError{kind: ErrJSON, err: err}
+			}
+			key := token.(string)
+			var val interface{}
+			if err := decoder.Decode(&val); err != nil {
+				return nil, &parseError{kind: ErrJSON, err: err}
+			}
+			strVal, err := jsonString(val)
+			if err != nil {
+				return nil, &parseError{kind: ErrJSON, err: err}
+			}
+			if seen[key] {
+				// As with json.Unmarshal, the last value for a duplicate
+				// key wins.
+				vals := data.Values[key]
+				vals[len(vals)-1] = opts.value(strVal)
+				continue
+			}
+			if err := opts.add(data, key, strVal); err != nil {
+				return nil, err
+			}
+			seen[key] = true
+		}
+		// consume the closing brace
+		if _, err := decoder.Token(); err != nil {
+			return nil, &parseError{kind: ErrJSON, err: err}
+		}
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		if err == nil {
+			err = errors.New("json: invalid data after top-level value")
+		}
+		return nil, &parseError{kind: ErrJSON, err: err}
 	}
+	return raw.Bytes(), nil
+}
+
+// jsonString converts a value decoded from json to a string.
+func jsonString(val interface{}) (string, error) {
 	// Whatever the underlying type is, we need to convert it to a
 	// string. There are only a few possible types, so we can just
 	// do a type switch over the possibilities.
-	for key, val := range rawData {
-		switch val.(type) {
-		case string, bool, float64:
-			values.Add(key, fmt.Sprint(val))
-		case nil:
-			values.Add(key, "")
-		case map[string]interface{}, []interface{}:
-			// for more complicated data structures, convert back to
-			// a JSON string and let user decide how to unmarshal
-			jsonVal, err := json.Marshal(val)
-			if err != nil {
-				return err
-			}
-			values.Add(key, string(jsonVal))
+	switch val.(type) {
+	case string, bool, float64:
+		return fmt.Sprint(val), nil
+	case map[string]interface{}, []interface{}:
+		// for more complicated data structures, convert back to
+		// a JSON string and let user decide how to unmarshal
+		jsonVal, err := json.Marshal(val)
+		if err != nil {
+			return "", err
 		}
+		return string(jsonVal), nil
 	}
-	return nil
+	return "", nil
 }
 
 // Add adds the value to key. It appends to any existing values associated with key.
@@ -148,9 +701,22 @@ func (d *Data) Add(key string, value string) {
 	d.Values.Add(key, value)
 }
 
-// AddFile adds the multipart form file to data with the given key.
+// AddFile adds the multipart form file to data with the given key. It
+// replaces any existing files associated with key.
 func (d *Data) AddFile(key string, file *multipart.FileHeader) {
 	d.Files[key] = file
+	delete(d.fileHeaders, key)
+}
+
+// addFiles associates all of files with key. files must not be empty.
+func (d *Data) addFiles(key string, files []*multipart.FileHeader) {
+	d.AddFile(key, files[0])
+	if len(files) > 1 {
+		if d.fileHeaders == nil {
+			d.fileHeaders = map[string][]*multipart.FileHeader{}
+		}
+		d.fileHeaders[key] = append([]*multipart.FileHeader(nil), files...)
+	}
 }
 
 // Del deletes the values associated with key.
@@ -158,10 +724,155 @@ func (d *Data) Del(key string) {
 	d.Values.Del(key)
 }
 
-// DelFile deletes the file associated with key (if any).
+// DelAll deletes the values associated with each of keys and returns the
+// number of keys which were actually present.
+func (d *Data) DelAll(keys ...string) int {
+	count := 0
+	for _, key := range keys {
+		if d.KeyExists(key) {
+			count++
+		}
+		d.Del(key)
+	}
+	return count
+}
+
+// DelFile deletes the files associated with key (if any).
 // If there is no file associated with key, it does nothing.
 func (d *Data) DelFile(key string) {
 	delete(d.Files, key)
+	delete(d.fileHeaders, key)
+}
+
+// Rename moves the values and files (if any) associated with oldKey to newKey
+// and deletes oldKey. If newKey already has values, the values from oldKey
+// are appended to them. The files for oldKey are discarded if newKey already
+// has a file. If oldKey does not exist
+// or is the same as newKey, Rename does nothing.
+func (d *Data) Rename(oldKey string, newKey string) {
+	if oldKey == newKey {
+		return
+	}
+	if vals, found := d.Values[oldKey]; found {
+		d.Values[newKey] = append(d.Values[newKey], vals...)
+		delete(d.Values, oldKey)
+	}
+	if d.FileExists(oldKey) {
+		if !d.FileExists(newKey) {
+			d.addFiles(newKey, d.FileHeaders(oldKey))
+		}
+		d.DelFile(oldKey)
+	}
+}
+
+// Clone returns a deep copy of d. The values for each key are copied, so
+// the result can be safely modified without affecting d. Note that the
+// *multipart.FileHeader for each file is shared between d and the result.
+func (d *Data) Clone() *Data {
+	clone := newData()
+	for key, vals := range d.Values {
+		clone.Values[key] = append([]string(nil), vals...)
+	}
+	for key := range d.Files {
+		clone.addFiles(key, d.FileHeaders(key))
+	}
+	if d.jsonBody != nil {
+		clone.jsonBody = append([]byte(nil), d.jsonBody...)
+	}
+	if d.nested != nil {
+		clone.nested = expandNested(clone.Values)
+	}
+	if d.rawBody != nil {
+		clone.rawBody = append([]byte(nil), d.rawBody...)
+	}
+	clone.contentLength = d.contentLength
+	return clone
+}
+
+// ToURLValues returns a copy of the values in d as url.Values. The result
+// can be safely modified or passed to other libraries without affecting d.
+// Files are not included.
+func (d *Data) ToURLValues() url.Values {
+	return url.Values(d.ToMap())
+}
+
+// ToMap is like ToURLValues, but returns a plain map[string][]string.
+func (d *Data) ToMap() map[string][]string {
+	m := make(map[string][]string, len(d.Values))
+	for key, vals := range d.Values {
+		m[key] = append([]string(nil), vals...)
+	}
+	return m
+}
+
+// Only returns a new Data containing only the values and files for the given
+// keys. Values are copied, so the result is independent of d. The original
+// json body (if any) is not included, so BindJSON on the result is a no-op.
+// Only is useful as a whitelist to guard against mass assignment.
+func (d *Data) Only(keys ...string) *Data {
+	allowed := map[string]bool{}
+	for _, key := range keys {
+		allowed[key] = true
+	}
+	return d.filter(func(key string) bool { return allowed[key] })
+}
+
+// Except returns a new Data containing all the values and files in d except
+// for those with the given keys. As with Only, values are copied and the
+// original json body is not included.
+func (d *Data) Except(keys ...string) *Data {
+	excluded := map[string]bool{}
+	for _, key := range keys {
+		excluded[key] = true
+	}
+	return d.filter(func(key string) bool { return !excluded[key] })
+}
+
+// filter returns a new Data holding copies of the values and files in d for
+// which keep returns true.
+func (d *Data) filter(keep func(key string) bool) *Data {
+	result := newData()
+	for key, vals := range d.Values {
+		if keep(key) {
+			result.Values[key] = append([]string(nil), vals...)
+		}
+	}
+	for key := range d.Files {
+		if keep(key) {
+			result.addFiles(key, d.FileHeaders(key))
+		}
+	}
+	return result
+}
+
+// Merge adds all the values and files from other to d. Values are appended
+// to any existing values for the same key (as with Add), so values already in
+// d take precedence for methods which get the first element for a key (e.g.
+// Get). Files in other are only added if d does not already have a file for
+// the same key.
+func (d *Data) Merge(other *Data) {
+	for key, vals := range other.Values {
+		for _, val := range vals {
+			d.Add(key, val)
+		}
+	}
+	for key := range other.Files {
+		if !d.FileExists(key) {
+			d.addFiles(key, other.FileHeaders(key))
+		}
+	}
+}
+
+// MergeOverride adds all the values and files from other to d, replacing any
+// existing values or files for keys which exist in other. Values in other take
+// precedence, and keys which only exist in d are left unchanged.
+func (d *Data) MergeOverride(other *Data) {
+	for key, vals := range other.Values {
+		d.Values[key] = append([]string(nil), vals...)
+	}
+	for key := range other.Files {
+		d.addFiles(key, other.FileHeaders(key))
+	}
 }
 
 // Encode encodes the values into “URL encoded” form ("bar=baz&foo=quux") sorted by key.
@@ -171,6 +882,122 @@ func (d *Data) Encode() string {
 	return d.Values.Encode()
 }
 
+// EncodeOrdered is like Encode, but the given keys are encoded first, in the
+// order given, followed by any remaining keys in sorted order. Values for each
+// key are encoded in the order they were added. Keys which do not exist in d
+// are ignored. This is useful for producing canonical strings for request
+// signing.
+func (d *Data) EncodeOrdered(keys ...string) string {
+	ordered := []string{}
+	seen := map[string]bool{}
+	for _, key := range keys {
+		if d.KeyExists(key) && !seen[key] {
+			ordered = append(ordered, key)
+			seen[key] = true
+		}
+	}
+	for _, key := range d.Keys() {
+		if !seen[key] {
+			ordered = append(ordered, key)
+		}
+	}
+	pairs := []string{}
+	for _, key := range ordered {
+		for _, val := range d.Values[key] {
+			pairs = append(pairs, url.QueryEscape(key)+"="+url.QueryEscape(val))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// String returns a human-readable representation of the values in d, sorted
+// by key, e.g. "age=[25] name=[bob bill]". Unlike Encode, values are not
+// escaped, so the result is intended for debugging and logging. Files are not
+// included.
+func (d Data) String() string {
+	pairs := make([]string, 0, len(d.Values))
+	for _, key := range d.Keys() {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", key, d.Values[key]))
+	}
+	return strings.Join(pairs, " ")
+}
+
+// MarshalJSON implements json.Marshaler. The values in d are encoded as an
+// object mapping each key to an array of all of its values, e.g.
+// {"name":["bob","bill"]}, with keys in sorted order. Unlike Encode, the
+// result is json rather than a url-encoded form. Files and the original json
+// body (if any) are not included.
+func (d Data) MarshalJSON() ([]byte, error) {
+	if d.Values == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(map[string][]string(d.Values))
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It is the inverse of
+// MarshalJSON and replaces any existing values in d. Files in d are left
+// unchanged.
+func (d *Data) UnmarshalJSON(b []byte) error {
+	values := url.Values{}
+	if err := json.Unmarshal(b, (*map[string][]string)(&values)); err != nil {
+		return err
+	}
+	if values == nil {
+		// b was null
+		values = url.Values{}
+	}
+	d.Values = values
+	if d.Files == nil {
+		d.Files = map[string]*multipart.FileHeader{}
+	}
+	return nil
+}
+
+// Each calls fn for every value of every key in d, in sorted key order. The
+// values for each key are passed in the order they were added. Files are not
+// included. fn should not modify d.
+func (d Data) Each(fn func(key string, value string)) {
+	for _, key := range d.Keys() {
+		for _, val := range d.Values[key] {
+			fn(key, val)
+		}
+	}
+}
+
+// Keys returns the keys of d.Values in sorted order. A new slice is returned
+// on each call, so it can be safely modified. Files are not included.
+func (d Data) Keys() []string {
+	keys := make([]string, 0, len(d.Values))
+	for key := range d.Values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Len returns the number of distinct keys in d.Values. Files are not
+// included.
+func (d Data) Len() int {
+	return len(d.Values)
+}
+
+// RawBody returns the exact bytes of the body of the request d was parsed
+// from, before any decompression. It is only available if d was parsed with
+// Options.KeepRawBody set, and returns nil otherwise. The result should not be
+// modified.
+func (d Data) RawBody() []byte {
+	return d.rawBody
+}
+
+// ContentLength returns the length in bytes of the body of the request d was
+// parsed from, as given by the Content-Length header (before any
+// decompression). It returns -1 if the length is unknown, e.g. because the
+// body was chunked or d was created by a function such as CreateFromMap
+// instead of by parsing a request.
+func (d Data) ContentLength() int64 {
+	return d.contentLength
+}
+
 // Get gets the first value associated with the given key. If there are no values
 // associated with the key, Get returns the empty string. To access multiple values,
 // use the map directly.
@@ -178,6 +1005,63 @@ func (d Data) Get(key string) string {
 	return d.Values.Get(key)
 }
 
+// GetFirst is an alias for Get. It returns the first value associated with
+// the given key, which for parsed requests is the value from the body if
+// there is one. It can be used alongside GetLast to make the intent clear.
+func (d Data) GetFirst(key string) string {
+	return d.Get(key)
+}
+
+// GetLast returns the last value associated with the given key, e.g. a value
+// which was overridden later in the form. If there are no values associated
+// with the key, GetLast returns the empty string.
+func (d Data) GetLast(key string) string {
+	vals := d.Values[key]
+	if len(vals) == 0 {
+		return ""
+	}
+	return vals[len(vals)-1]
+}
+
+// GetAny returns the first non-empty value among keys, checked in order. It
+// is useful when clients may send a value under one of several names, e.g.
+// GetAny("email", "email_address"). Keys which do not exist or have an empty
+// value are skipped. If none of keys has a value, GetAny returns the empty
+// string.
+func (d Data) GetAny(keys ...string) string {
+	for _, key := range keys {
+		if val := d.Get(key); val != "" {
+			return val
+		}
+	}
+	return ""
+}
+
+// GetFold is like Get, but compares keys case-insensitively (see
+// strings.EqualFold), so GetFold("firstname") will find a value stored under
+// "FirstName". An exact match takes precedence. If more than one key matches
+// case-insensitively, the first in sorted order is used.
+func (d Data) GetFold(key string) string {
+	if d.KeyExists(key) {
+		return d.Get(key)
+	}
+	for _, k := range d.Keys() {
+		if strings.EqualFold(k, key) {
+			return d.Get(k)
+		}
+	}
+	return ""
+}
+
+// GetStringDefault returns the first value associated with the given key, or
+// def if the key does not exist or its value is empty.
+func (d Data) GetStringDefault(key string, def string) string {
+	if val := d.Get(key); val != "" {
+		return val
+	}
+	return def
+}
+
 // GetFile returns the multipart form file associated with key, if any, as a *multipart.FileHeader.
 // If there is no file associated with key, it returns nil. If you just want the body of the
 // file, use GetFileBytes.
@@ -185,11 +1069,56 @@ func (d Data) GetFile(key string) *multipart.FileHeader {
 	return d.Files[key]
 }
 
+// File is like GetFile, but returns ErrKeyNotFound if there is no file
+// associated with key.
+func (d Data) File(key string) (*multipart.FileHeader, error) {
+	file, found := d.Files[key]
+	if !found {
+		return nil, ErrKeyNotFound
+	}
+	return file, nil
+}
+
+// FileHeaders returns every multipart form file associated with key, in the
+// order they appeared in the request, e.g. for an input which allows multiple
+// files to be selected. The first element is always the same as GetFile. If
+// there is no file associated with key, it returns nil. The result is a copy,
+// so it can be safely modified. If Files has been modified directly so that
+// key holds a different file, only that file is returned.
+func (d Data) FileHeaders(key string) []*multipart.FileHeader {
+	file, found := d.Files[key]
+	if !found {
+		return nil
+	}
+	if files := d.fileHeaders[key]; len(files) != 0 && files[0] == file {
+		return append([]*multipart.FileHeader(nil), files...)
+	}
+	return []*multipart.FileHeader{file}
+}
+
+// FileContentType returns the Content-Type declared by the client for the
+// file associated with key, e.g. "image/png". If there is no file associated
+// with key, it returns the empty string. Note that the declared type is not
+// verified against the contents of the file.
+func (d Data) FileContentType(key string) string {
+	file := d.GetFile(key)
+	if file == nil {
+		return ""
+	}
+	return file.Header.Get("Content-Type")
+}
+
 // Set sets the key to value. It replaces any existing values.
 func (d *Data) Set(key string, value string) {
 	d.Values.Set(key, value)
 }
 
+// SetValues sets the key to values. It replaces any existing values. values
+// is copied, so later changes to it do not affect d.
+func (d *Data) SetValues(key string, values []string) {
+	d.Values[key] = append([]string(nil), values...)
+}
+
 // KeyExists returns true iff data.Values[key] exists. When parsing a request body, the key
 // is considered to be in existence if it was provided in the request body, even if its value
 // is empty.
@@ -198,6 +1127,13 @@ func (d Data) KeyExists(key string) bool {
 	return found
 }
 
+// HasValue returns true iff data.Values[key] exists and its first element is
+// not empty or only whitespace. Unlike KeyExists, a key which was provided with
+// an empty value is not considered to have a value.
+func (d Data) HasValue(key string) bool {
+	return strings.TrimSpace(d.Get(key)) != ""
+}
+
 // FileExists returns true iff data.Files[key] exists. When parsing a request body, the key
 // is considered to be in existence if it was provided in the request body, even if the file
 // is empty.
@@ -207,42 +1143,311 @@ func (d Data) FileExists(key string) bool {
 }
 
 // GetInt returns the first element in data[key] converted to an int.
+// It panics if the value cannot be converted. Use GetIntErr if the
+// value comes from untrusted input.
 func (d Data) GetInt(key string) int {
+	result, err := d.GetIntErr(key)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// GetIntErr returns the first element in data[key] converted to an int.
+// Unlike GetInt, it returns an error instead of panicking if the value
+// cannot be converted. If the key does not exist, it returns 0 and a nil
+// error.
+func (d Data) GetIntErr(key string) (int, error) {
 	if !d.KeyExists(key) || len(d.Values[key]) == 0 {
-		return 0
+		return 0, nil
 	}
-	str := d.Get(key)
-	if result, err := strconv.Atoi(str); err != nil {
+	return strconv.Atoi(d.Get(key))
+}
+
+// GetIntDefault returns the first element in data[key] converted to an int,
+// or def if the key does not exist or its value is empty. Like GetInt, it
+// panics if the value cannot be converted.
+func (d Data) GetIntDefault(key string, def int) int {
+	if d.Get(key) == "" {
+		return def
+	}
+	return d.GetInt(key)
+}
+
+// GetIntClamped returns the first element in data[key] converted to an int
+// and clamped to the range [min, max]. It returns def if the key does not
+// exist, its value is empty, or it cannot be converted to an int, so it never
+// panics. This is useful for values like page numbers and sizes, where bad
+// input should be silently corrected rather than rejected.
+func (d Data) GetIntClamped(key string, min int, max int, def int) int {
+	result, err := strconv.Atoi(d.Get(key))
+	if err != nil {
+		return def
+	}
+	if result < min {
+		return min
+	} else if result > max {
+		return max
+	}
+	return result
+}
+
+// GetInt64 returns the first element in data[key] converted to an int64.
+// It panics if the value cannot be converted or does not fit in 64 bits.
+// Use GetInt64Err if the value comes from untrusted input.
+func (d Data) GetInt64(key string) int64 {
+	result, err := d.GetInt64Err(key)
+	if err != nil {
 		panic(err)
-	} else {
-		return result
 	}
+	return result
 }
 
-// GetFloat returns the first element in data[key] converted to a float.
-func (d Data) GetFloat(key string) float64 {
+// GetInt64Err returns the first element in data[key] converted to an int64.
+// Unlike GetInt64, it returns an error instead of panicking if the value
+// cannot be converted. If the key does not exist, it returns 0 and a nil
+// error.
+func (d Data) GetInt64Err(key string) (int64, error) {
 	if !d.KeyExists(key) || len(d.Values[key]) == 0 {
-		return 0.0
+		return 0, nil
 	}
-	str := d.Get(key)
-	if result, err := strconv.ParseFloat(str, 64); err != nil {
+	return strconv.ParseInt(d.Get(key), 10, 64)
+}
+
+// GetUint64 returns the first element in data[key] converted to a uint64.
+// It panics if the value cannot be converted or does not fit in 64 bits.
+// Use GetUint64Err if the value comes from untrusted input.
+func (d Data) GetUint64(key string) uint64 {
+	result, err := d.GetUint64Err(key)
+	if err != nil {
 		panic(err)
-	} else {
-		return result
 	}
+	return result
+}
+
+// GetUint64Err returns the first element in data[key] converted to a uint64.
+// Unlike GetUint64, it returns an error instead of panicking if the value
+// cannot be converted. If the key does not exist, it returns 0 and a nil
+// error.
+func (d Data) GetUint64Err(key string) (uint64, error) {
+	if !d.KeyExists(key) || len(d.Values[key]) == 0 {
+		return 0, nil
+	}
+	return strconv.ParseUint(d.Get(key), 10, 64)
+}
+
+// GetFloat returns the first element in data[key] converted to a float.
+// It panics if the value cannot be converted. Use GetFloatErr if the
+// value comes from untrusted input.
+func (d Data) GetFloat(key string) float64 {
+	result, err := d.GetFloatErr(key)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// GetFloatErr returns the first element in data[key] converted to a float.
+// Unlike GetFloat, it returns an error instead of panicking if the value
+// cannot be converted. If the key does not exist, it returns 0 and a nil
+// error.
+func (d Data) GetFloatErr(key string) (float64, error) {
+	if !d.KeyExists(key) || len(d.Values[key]) == 0 {
+		return 0.0, nil
+	}
+	return strconv.ParseFloat(d.Get(key), 64)
 }
 
 // GetBool returns the first element in data[key] converted to a bool.
+// It panics if the value cannot be converted. Use GetBoolErr if the
+// value comes from untrusted input.
 func (d Data) GetBool(key string) bool {
+	result, err := d.GetBoolErr(key)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// GetBoolErr returns the first element in data[key] converted to a bool.
+// Unlike GetBool, it returns an error instead of panicking if the value
+// cannot be converted. If the key does not exist, it returns false and a
+// nil error.
+func (d Data) GetBoolErr(key string) (bool, error) {
 	if !d.KeyExists(key) || len(d.Values[key]) == 0 {
+		return false, nil
+	}
+	return strconv.ParseBool(d.Get(key))
+}
+
+// GetBoolDefault returns the first element in data[key] converted to a bool,
+// or def if the key does not exist or its value is empty. This is useful for
+// distinguishing a value which was not provided from one which is false. Like
+// GetBool, it panics if the value cannot be converted.
+func (d Data) GetBoolDefault(key string, def bool) bool {
+	if d.Get(key) == "" {
+		return def
+	}
+	return d.GetBool(key)
+}
+
+// GetBoolLoose returns the first element in data[key] converted to a bool,
+// accepting a broader set of values than GetBool. "on", "yes", "y", "1", and
+// "true" are true, compared case-insensitively. Anything else, including
+// "off", "no", "n", "0", "false", an empty value, or a missing key, is false.
+// This is useful for html checkboxes (which send "on") and it never panics.
+func (d Data) GetBoolLoose(key string) bool {
+	switch strings.ToLower(strings.TrimSpace(d.Get(key))) {
+	case "on", "yes", "y", "1", "true":
+		return true
+	default:
 		return false
 	}
-	str := d.Get(key)
-	if result, err := strconv.ParseBool(str); err != nil {
-		panic(err)
-	} else {
-		return result
+}
+
+// GetTime returns the first element in data[key] parsed as a time.Time using
+// the given layout (see time.Parse). If the key does not exist or its value is
+// empty, it returns the zero time.Time and a nil error.
+func (d Data) GetTime(key string, layout string) (time.Time, error) {
+	return d.GetTimeInLocation(key, layout, time.UTC)
+}
+
+// GetTimeInLocation is like GetTime but interprets a time without time zone
+// information as being in loc (see time.ParseInLocation).
+func (d Data) GetTimeInLocation(key string, layout string, loc *time.Location) (time.Time, error) {
+	if d.Get(key) == "" {
+		return time.Time{}, nil
 	}
+	return time.ParseInLocation(layout, d.Get(key), loc)
+}
+
+// GetDuration returns the first element in data[key] parsed as a
+// time.Duration (see time.ParseDuration), e.g. "30s" or "1h30m". If the key
+// does not exist or its value is empty, it returns 0 and a nil error.
+func (d Data) GetDuration(key string) (time.Duration, error) {
+	if d.Get(key) == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(d.Get(key))
+}
+
+// GetUnixTime returns the first element in data[key] parsed as a Unix
+// timestamp in seconds, e.g. "1136214245". If the key does not exist or its
+// value is empty, it returns the zero time.Time and a nil error. It returns an
+// error if the value is not an integer.
+func (d Data) GetUnixTime(key string) (time.Time, error) {
+	if d.Get(key) == "" {
+		return time.Time{}, nil
+	}
+	sec, err := strconv.ParseInt(d.Get(key), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("forms: could not convert %s to a unix time: %s", key, err)
+	}
+	return time.Unix(sec, 0), nil
+}
+
+// GetUnixMilliTime is like GetUnixTime, but parses the value as a Unix
+// timestamp in milliseconds, as used by e.g. javascript's Date.now().
+func (d Data) GetUnixMilliTime(key string) (time.Time, error) {
+	if d.Get(key) == "" {
+		return time.Time{}, nil
+	}
+	msec, err := strconv.ParseInt(d.Get(key), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("forms: could not convert %s to a unix time: %s", key, err)
+	}
+	return time.Unix(msec/1000, (msec%1000)*int64(time.Millisecond)), nil
+}
+
+// GetIP returns the first element in data[key] parsed as an IPv4 or IPv6
+// address. It returns an error if the value is not a valid IP address. If the
+// key does not exist or its value is empty, it returns nil and a nil error.
+func (d Data) GetIP(key string) (net.IP, error) {
+	val := d.Get(key)
+	if val == "" {
+		return nil, nil
+	}
+	ip := net.ParseIP(val)
+	if ip == nil {
+		return nil, fmt.Errorf("forms: could not convert %s to an IP address: %q", key, val)
+	}
+	return ip, nil
+}
+
+// GetDecimal returns the first element in data[key] parsed as an exact
+// rational number (see big.Rat.SetString), e.g. "19.99" or "1/3". This is
+// useful for values such as money where float64 would lose precision. If the
+// key does not exist or its value is empty, it returns nil and a nil error.
+func (d Data) GetDecimal(key string) (*big.Rat, error) {
+	val := d.Get(key)
+	if val == "" {
+		return nil, nil
+	}
+	result, ok := new(big.Rat).SetString(val)
+	if !ok {
+		return nil, fmt.Errorf("forms: could not convert %s to a decimal: %q", key, val)
+	}
+	return result, nil
+}
+
+// GetRune returns the first element in data[key] as a single rune. It returns
+// an error if the value is not exactly one valid UTF-8 encoded rune. If the key
+// does not exist or its value is empty, it returns 0 and a nil error.
+func (d Data) GetRune(key string) (rune, error) {
+	val := d.Get(key)
+	if val == "" {
+		return 0, nil
+	}
+	r, size := utf8.DecodeRuneInString(val)
+	// A correctly encoded U+FFFD also decodes to RuneError, but with a
+	// size of 3 rather than 1.
+	if (r == utf8.RuneError && size == 1) || size != len(val) {
+		return 0, fmt.Errorf("forms: could not convert %s to a single character: %q", key, val)
+	}
+	return r, nil
+}
+
+// GetHexColor returns the first element in data[key] parsed as a hex color,
+// such as the value of an <input type="color">. Both 3-digit ("#fff") and
+// 6-digit ("#ff00aa") forms are accepted, with or without the leading "#". The
+// returned color is fully opaque. If the key does not exist or its value is
+// empty, it returns the zero color.RGBA and a nil error.
+func (d Data) GetHexColor(key string) (color.RGBA, error) {
+	val := d.Get(key)
+	if val == "" {
+		return color.RGBA{}, nil
+	}
+	hex := strings.TrimPrefix(val, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	rgb, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return color.RGBA{}, fmt.Errorf("forms: could not convert %s to a hex color: %q", key, val)
+	}
+	return color.RGBA{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 0xff}, nil
+}
+
+// GetUUID returns the first element in data[key] parsed as a UUID in the
+// hyphenated 8-4-4-4-12 hex format described by RFC 4122, e.g.
+// "123e4567-e89b-12d3-a456-426655440000". Upper and lower case hex digits are
+// both accepted. If the key does not exist or its value is empty, it returns
+// the zero value and a nil error.
+func (d Data) GetUUID(key string) ([16]byte, error) {
+	var uuid [16]byte
+	val := d.Get(key)
+	if val == "" {
+		return uuid, nil
+	}
+	invalid := fmt.Errorf("forms: could not convert %s to a UUID: %q", key, val)
+	if len(val) != 36 || val[8] != '-' || val[13] != '-' || val[18] != '-' || val[23] != '-' {
+		return uuid, invalid
+	}
+	digits := strings.Replace(val, "-", "", -1)
+	if _, err := hex.Decode(uuid[:], []byte(digits)); err != nil || len(digits) != 32 {
+		return [16]byte{}, invalid
+	}
+	return uuid, nil
 }
 
 // GetBytes returns the first element in data[key] converted to a slice of bytes.
@@ -250,6 +1455,29 @@ func (d Data) GetBytes(key string) []byte {
 	return []byte(d.Get(key))
 }
 
+// GetBase64 returns the first element in data[key] decoded as standard base64
+// (see base64.StdEncoding). It is useful for small binary values such as
+// signatures or thumbnails. If the key does not exist or its value is empty,
+// it returns nil and a nil error. Unlike GetBytes, which returns the raw value,
+// GetBase64 returns an error if the value is not valid base64.
+func (d Data) GetBase64(key string) ([]byte, error) {
+	return d.getBase64(key, base64.StdEncoding)
+}
+
+// GetBase64URL is like GetBase64, but uses the URL-safe base64 alphabet (see
+// base64.URLEncoding).
+func (d Data) GetBase64URL(key string) ([]byte, error) {
+	return d.getBase64(key, base64.URLEncoding)
+}
+
+func (d Data) getBase64(key string, enc *base64.Encoding) ([]byte, error) {
+	val := d.Get(key)
+	if val == "" {
+		return nil, nil
+	}
+	return enc.DecodeString(val)
+}
+
 // GetFileBytes returns the body of the file associated with key. If there is no
 // file associated with key, it returns nil (not an error). It may return an error if
 // there was a problem reading the file. If you need to know whether or not the file
@@ -267,6 +1495,77 @@ func (d Data) GetFileBytes(key string) ([]byte, error) {
 	}
 }
 
+// ReadFile returns the body of the file associated with key, reading at most
+// maxBytes bytes. Unlike GetFileBytes, it never reads more than maxBytes into
+// memory. If the file is larger than maxBytes, it returns ErrFileTooLarge. If
+// there is no file associated with key, it returns ErrKeyNotFound.
+func (d Data) ReadFile(key string, maxBytes int64) ([]byte, error) {
+	fileHeader, found := d.Files[key]
+	if !found {
+		return nil, ErrKeyNotFound
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > maxBytes {
+		return nil, ErrFileTooLarge
+	}
+	return body, nil
+}
+
+// GetStrings returns all the values associated with key, in order. If there
+// are no values associated with the key, it returns nil. This is useful for
+// fields which may be provided more than once, such as a group of checkboxes.
+func (d Data) GetStrings(key string) []string {
+	return d.Values[key]
+}
+
+// GetInts returns all the values associated with key converted to ints, in
+// order. If any value cannot be converted, it returns an error which includes
+// the index of the offending value. If there are no values associated with
+// the key, it returns nil and a nil error.
+func (d Data) GetInts(key string) ([]int, error) {
+	vals := d.Values[key]
+	if len(vals) == 0 {
+		return nil, nil
+	}
+	results := make([]int, len(vals))
+	for i, val := range vals {
+		result, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("forms: could not convert %s[%d] to an int: %s", key, i, err)
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// GetFloats returns all the values associated with key converted to floats,
+// in order. If any value cannot be converted, it returns an error which
+// includes the index of the offending value. If there are no values associated
+// with the key, it returns nil and a nil error.
+func (d Data) GetFloats(key string) ([]float64, error) {
+	vals := d.Values[key]
+	if len(vals) == 0 {
+		return nil, nil
+	}
+	results := make([]float64, len(vals))
+	for i, val := range vals {
+		result, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return nil, fmt.Errorf("forms: could not convert %s[%d] to a float: %s", key, i, err)
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
 // GetStringsSplit returns the first element in data[key] split into a slice delimited by delim.
 func (d Data) GetStringsSplit(key string, delim string) []string {
 	if !d.KeyExists(key) || len(d.Values[key]) == 0 {
@@ -275,6 +1574,63 @@ func (d Data) GetStringsSplit(key string, delim string) []string {
 	return strings.Split(d.Values[key][0], delim)
 }
 
+// GetIntsSplit returns the first element in data[key] split by delim, with
+// each element converted to an int, e.g. "1|2|3" with delim "|" results in
+// []int{1, 2, 3}. Leading and trailing whitespace around each element is
+// ignored. If any element cannot be converted, it returns an error which
+// includes the offending element. If the key does not exist or its value is
+// empty, it returns nil and a nil error.
+func (d Data) GetIntsSplit(key string, delim string) ([]int, error) {
+	if d.Get(key) == "" {
+		return nil, nil
+	}
+	parts := d.GetStringsSplit(key, delim)
+	results := make([]int, len(parts))
+	for i, part := range parts {
+		result, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("forms: could not convert element %q of %s to an int: %s", part, key, err)
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// GetStringList returns the first element in data[key] split on commas, with
+// leading and trailing whitespace trimmed from each element. Empty elements
+// (e.g. from a trailing comma) are dropped, so "a, b ,c," results in
+// []string{"a", "b", "c"}. If the key does not exist, it returns nil.
+func (d Data) GetStringList(key string) []string {
+	var results []string
+	for _, part := range d.GetStringsSplit(key, ",") {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			results = append(results, trimmed)
+		}
+	}
+	return results
+}
+
+// GetCSV returns the first element in data[key] parsed as a single CSV record
+// (see encoding/csv). Unlike GetStringsSplit, quoted fields may contain commas,
+// so `a,"b,c",d` results in []string{"a", "b,c", "d"}. If the key does not
+// exist or its value is empty, it returns nil and a nil error. It returns an
+// error if the value is malformed or contains more than one record.
+func (d Data) GetCSV(key string) ([]string, error) {
+	val := d.Get(key)
+	if val == "" {
+		return nil, nil
+	}
+	r := csv.NewReader(strings.NewReader(val))
+	record, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("forms: could not parse %s as csv: %s", key, err)
+	}
+	if _, err := r.Read(); err != io.EOF {
+		return nil, fmt.Errorf("forms: could not parse %s as csv: expected a single record", key)
+	}
+	return record, nil
+}
+
 // BindJSON binds v to the json data in the request body. It calls json.Unmarshal and
 // sets the value of v.
 func (d Data) BindJSON(v interface{}) error {
@@ -324,6 +1680,18 @@ func (d Data) GetAndUnmarshalJSON(key string, v interface{}) error {
 	return nil
 }
 
+// GetJSON assumes that the first element in data[key] is a json string and
+// unmarshals it into dst, which should be a pointer to some data structure.
+// Unlike GetAndUnmarshalJSON, it returns ErrKeyNotFound if there is no value
+// associated with key, so that callers can distinguish between a value which
+// was not provided and one which is not valid json.
+func (d Data) GetJSON(key string, dst interface{}) error {
+	if d.Get(key) == "" {
+		return ErrKeyNotFound
+	}
+	return json.Unmarshal([]byte(d.Get(key)), dst)
+}
+
 // Validator returns a Validator which can be used to easily validate data.
 func (d *Data) Validator() *Validator {
 	return &Validator{
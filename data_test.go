@@ -6,18 +6,135 @@ package forms
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"image/color"
 	"io"
 	"io/ioutil"
+	"math"
+	"math/big"
 	"mime/multipart"
+	"net"
 	"net/http"
+	"net/textproto"
 	"net/url"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
+func TestEncodeOrdered(t *testing.T) {
+	data := newData()
+	data.Add("amount", "10.00")
+	data.Add("currency", "USD")
+	data.Add("timestamp", "12345")
+	data.Add("note", "for you & me")
+	data.Add("tag", "b")
+	data.Add("tag", "a")
+
+	expected := "timestamp=12345&amount=10.00&currency=USD&note=for+you+%26+me&tag=b&tag=a"
+	if got := data.EncodeOrdered("timestamp", "amount", "missing", "currency"); got != expected {
+		t.Errorf("Expected EncodeOrdered to return\n%s\nbut got\n%s", expected, got)
+	}
+
+	// With no keys, it should match Encode
+	if got, expected := data.EncodeOrdered(), data.Encode(); got != expected {
+		t.Errorf("Expected EncodeOrdered() to match Encode().\nExpected %s\nbut got %s", expected, got)
+	}
+}
+
+func TestString(t *testing.T) {
+	data := newData()
+	data.Add("name", "bob")
+	data.Add("name", "bill")
+	data.Add("age", "25")
+	expected := "age=[25] name=[bob bill]"
+	if got := data.String(); got != expected {
+		t.Errorf(`Expected String() to return "%s" but got "%s"`, expected, got)
+	}
+	if got := fmt.Sprint(data); got != expected {
+		t.Errorf(`Expected fmt.Sprint to return "%s" but got "%s"`, expected, got)
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	data := newData()
+	data.Add("name", "bob")
+	data.Add("name", "bill")
+	data.Add("age", "25")
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"age":["25"],"name":["bob","bill"]}`
+	if string(encoded) != expected {
+		t.Errorf("Expected %s but got %s", expected, encoded)
+	}
+
+	decoded := &Data{}
+	if err := json.Unmarshal(encoded, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded.Values, data.Values) {
+		t.Errorf("Expected round trip to produce %v but got %v", data.Values, decoded.Values)
+	}
+	decoded.Add("color", "blue")
+
+	if err := json.Unmarshal([]byte(`{"name":"bob"}`), decoded); err == nil {
+		t.Error("Expected an error for a non-array value but got none.")
+	}
+	if encoded, err := json.Marshal(Data{}); err != nil || string(encoded) != "{}" {
+		t.Errorf("Expected zero Data to marshal to {} but got (%s, %v)", encoded, err)
+	}
+}
+
+func TestKeysAndLen(t *testing.T) {
+	data := newData()
+	data.Add("name", "bob")
+	data.Add("name", "bill")
+	data.Add("age", "25")
+	data.Add("color", "blue")
+
+	expected := []string{"age", "color", "name"}
+	keys := data.Keys()
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Expected keys %v but got %v", expected, keys)
+	}
+	keys[0] = "changed"
+	if got := data.Keys(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected a fresh slice from Keys but got %v", got)
+	}
+	if got := data.Len(); got != 3 {
+		t.Errorf("Expected Len to return 3 but got %d", got)
+	}
+	if got := newData().Len(); got != 0 {
+		t.Errorf("Expected Len of empty data to return 0 but got %d", got)
+	}
+}
+
+func TestEach(t *testing.T) {
+	data := newData()
+	data.Add("name", "bob")
+	data.Add("name", "bill")
+	data.Add("age", "25")
+
+	got := []string{}
+	data.Each(func(key string, value string) {
+		got = append(got, key+"="+value)
+	})
+	expected := []string{"age=25", "name=bob", "name=bill"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected Each to visit %v but got %v", expected, got)
+	}
+}
+
 func TestGet(t *testing.T) {
 	data := newData()
 	data.Values = map[string][]string{
@@ -51,6 +168,96 @@ func TestGet(t *testing.T) {
 	}
 }
 
+func TestGetFirstAndGetLast(t *testing.T) {
+	data := newData()
+	data.Add("color", "blue")
+	data.Add("color", "green")
+	data.Add("color", "red")
+	data.Add("name", "bob")
+
+	if got := data.GetFirst("color"); got != "blue" {
+		t.Errorf(`Expected GetFirst("color") to return "blue" but got "%s"`, got)
+	}
+	if got := data.GetLast("color"); got != "red" {
+		t.Errorf(`Expected GetLast("color") to return "red" but got "%s"`, got)
+	}
+	if first, last := data.GetFirst("name"), data.GetLast("name"); first != "bob" || last != "bob" {
+		t.Errorf(`Expected GetFirst and GetLast to return "bob" for a single value but got "%s" and "%s"`, first, last)
+	}
+	if got := data.GetLast("missing"); got != "" {
+		t.Errorf(`Expected GetLast to return "" for a missing key but got "%s"`, got)
+	}
+}
+
+func TestGetAny(t *testing.T) {
+	data := newData()
+	data.Add("email", "")
+	data.Add("email_address", "bob@example.com")
+	data.Add("mail", "bill@example.com")
+
+	if got := data.GetAny("missing", "email", "email_address", "mail"); got != "bob@example.com" {
+		t.Errorf(`Expected GetAny to return "bob@example.com" but got "%s"`, got)
+	}
+	if got := data.GetAny("mail", "email_address"); got != "bill@example.com" {
+		t.Errorf(`Expected GetAny to return "bill@example.com" but got "%s"`, got)
+	}
+	if got := data.GetAny("missing", "email"); got != "" {
+		t.Errorf(`Expected GetAny to return "" when no key has a value but got "%s"`, got)
+	}
+	if got := data.GetAny(); got != "" {
+		t.Errorf(`Expected GetAny to return "" with no keys but got "%s"`, got)
+	}
+}
+
+func TestGetFold(t *testing.T) {
+	data := newData()
+	data.Add("FirstName", "Bob")
+	data.Add("lastname", "Smith")
+	data.Add("LASTNAME", "Jones")
+
+	table := []struct {
+		key      string
+		expected string
+	}{
+		{key: "firstname", expected: "Bob"},
+		{key: "FIRSTNAME", expected: "Bob"},
+		{key: "LASTNAME", expected: "Jones"},
+		{key: "LastName", expected: "Jones"},
+		{key: "middleName", expected: ""},
+	}
+	for _, test := range table {
+		if got := data.GetFold(test.key); got != test.expected {
+			t.Errorf("%s was incorrect. Expected %s, but got %s.\n", test.key, test.expected, got)
+		}
+	}
+}
+
+func TestHasValue(t *testing.T) {
+	data := newData()
+	data.Add("name", "bob")
+	data.Add("empty", "")
+	data.Add("blank", "  ")
+
+	table := []struct {
+		key       string
+		keyExists bool
+		hasValue  bool
+	}{
+		{key: "name", keyExists: true, hasValue: true},
+		{key: "empty", keyExists: true, hasValue: false},
+		{key: "blank", keyExists: true, hasValue: false},
+		{key: "missing", keyExists: false, hasValue: false},
+	}
+	for _, test := range table {
+		if got := data.KeyExists(test.key); got != test.keyExists {
+			t.Errorf("KeyExists(%q) was incorrect. Expected %t, but got %t.", test.key, test.keyExists, got)
+		}
+		if got := data.HasValue(test.key); got != test.hasValue {
+			t.Errorf("HasValue(%q) was incorrect. Expected %t, but got %t.", test.key, test.hasValue, got)
+		}
+	}
+}
+
 func TestGetInt(t *testing.T) {
 	data := newData()
 	data.Values = map[string][]string{
@@ -84,11 +291,97 @@ func TestGetInt(t *testing.T) {
 	}
 }
 
+func TestGetIntErr(t *testing.T) {
+	data := newData()
+	data.Values = map[string][]string{
+		"age":    []string{"25", "33"},
+		"blank":  []string{""},
+		"word":   []string{"abc"},
+		"float":  []string{"4.2"},
+		"empty":  []string{},
+		"weight": []string{"155"},
+	}
+
+	table := []struct {
+		key         string
+		expected    int
+		expectError bool
+	}{
+		{key: "age", expected: 25},
+		{key: "weight", expected: 155},
+		{key: "height", expected: 0},
+		{key: "empty", expected: 0},
+		{key: "blank", expectError: true},
+		{key: "word", expectError: true},
+		{key: "float", expectError: true},
+	}
+
+	for _, test := range table {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("GetIntErr(%q) panicked: %v", test.key, r)
+				}
+			}()
+			got, err := data.GetIntErr(test.key)
+			if test.expectError {
+				if err == nil {
+					t.Errorf("Expected an error for %s but got none.", test.key)
+				}
+			} else if err != nil {
+				t.Errorf("Unexpected error for %s: %s", test.key, err)
+			} else if got != test.expected {
+				t.Errorf("%s was incorrect. Expected %d, but got %d.\n", test.key, test.expected, got)
+			}
+		}()
+	}
+}
+
+func TestGetInt64AndGetUint64(t *testing.T) {
+	data := newData()
+	data.Add("maxInt64", strconv.FormatInt(math.MaxInt64, 10))
+	data.Add("minInt64", strconv.FormatInt(math.MinInt64, 10))
+	data.Add("maxUint64", strconv.FormatUint(math.MaxUint64, 10))
+	data.Add("overInt64", "9223372036854775808")
+	data.Add("overUint64", "18446744073709551616")
+	data.Add("negative", "-1")
+
+	if got := data.GetInt64("maxInt64"); got != math.MaxInt64 {
+		t.Errorf("maxInt64 was incorrect. Expected %d, but got %d.", int64(math.MaxInt64), got)
+	}
+	if got := data.GetInt64("minInt64"); got != math.MinInt64 {
+		t.Errorf("minInt64 was incorrect. Expected %d, but got %d.", int64(math.MinInt64), got)
+	}
+	if got := data.GetUint64("maxUint64"); got != math.MaxUint64 {
+		t.Errorf("maxUint64 was incorrect. Expected %d, but got %d.", uint64(math.MaxUint64), got)
+	}
+	if got := data.GetInt64("missing"); got != 0 {
+		t.Errorf("Expected 0 for missing key but got %d.", got)
+	}
+	if got := data.GetUint64("missing"); got != 0 {
+		t.Errorf("Expected 0 for missing key but got %d.", got)
+	}
+
+	// Values which do not fit should return an error instead of silently
+	// overflowing.
+	for _, key := range []string{"overInt64"} {
+		if got, err := data.GetInt64Err(key); err == nil {
+			t.Errorf("Expected an error for %s but got %d.", key, got)
+		}
+	}
+	for _, key := range []string{"overUint64", "negative"} {
+		if got, err := data.GetUint64Err(key); err == nil {
+			t.Errorf("Expected an error for %s but got %d.", key, got)
+		}
+	}
+}
+
 func TestGetFloat(t *testing.T) {
 	data := newData()
 	data.Values = map[string][]string{
 		"age":    []string{"25.7", "33"},
 		"weight": []string{"42"},
+		"pi":     []string{"3.14"},
 	}
 
 	table := []struct {
@@ -103,6 +396,10 @@ func TestGetFloat(t *testing.T) {
 			key:      "weight",
 			expected: 42.0,
 		},
+		{
+			key:      "pi",
+			expected: 3.14,
+		},
 		{
 			key:      "height",
 			expected: 0.0,
@@ -115,6 +412,29 @@ func TestGetFloat(t *testing.T) {
 			t.Errorf("%s was incorrect. Expected %f, but got %f.\n", test.key, test.expected, got)
 		}
 	}
+
+	// An empty slice is treated the same as a missing key
+	data.Values["empty"] = []string{}
+	if got := data.GetFloat("empty"); got != 0.0 {
+		t.Errorf("empty was incorrect. Expected 0, but got %f.\n", got)
+	}
+}
+
+func TestGetFloatPanics(t *testing.T) {
+	data := newData()
+	data.Add("blank", "")
+	data.Add("word", "abc")
+
+	for _, key := range []string{"blank", "word"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected GetFloat(%q) to panic but it did not.", key)
+				}
+			}()
+			data.GetFloat(key)
+		}()
+	}
 }
 
 func TestGetBool(t *testing.T) {
@@ -155,220 +475,1655 @@ func TestGetBool(t *testing.T) {
 	}
 }
 
-func TestBytes(t *testing.T) {
+func TestGetFloatErrAndGetBoolErr(t *testing.T) {
 	data := newData()
-	data.Values = map[string][]string{
-		"name":       []string{"bob", "bill"},
-		"profession": []string{"plumber"},
+	data.Add("pi", "3.14")
+	data.Add("cool", "true")
+	data.Add("word", "abc")
+
+	if got, err := data.GetFloatErr("pi"); err != nil {
+		t.Error(err)
+	} else if got != 3.14 {
+		t.Errorf("pi was incorrect. Expected 3.14, but got %f.", got)
+	}
+	if got, err := data.GetBoolErr("cool"); err != nil {
+		t.Error(err)
+	} else if !got {
+		t.Error("cool was incorrect. Expected true, but got false.")
+	}
+	if got, err := data.GetFloatErr("missing"); err != nil || got != 0.0 {
+		t.Errorf("Expected (0, nil) for missing key but got (%f, %v).", got, err)
+	}
+	if got, err := data.GetBoolErr("missing"); err != nil || got {
+		t.Errorf("Expected (false, nil) for missing key but got (%t, %v).", got, err)
+	}
+	if _, err := data.GetFloatErr("word"); err == nil {
+		t.Error("Expected an error from GetFloatErr for a malformed value but got none.")
+	}
+	if _, err := data.GetBoolErr("word"); err == nil {
+		t.Error("Expected an error from GetBoolErr for a malformed value but got none.")
 	}
+}
+
+func TestGetTime(t *testing.T) {
+	data := newData()
+	data.Add("start", "2015-06-01T12:30:00Z")
+	data.Add("local", "2015-06-01 12:30")
+	data.Add("blank", "")
+
+	expected := time.Date(2015, 6, 1, 12, 30, 0, 0, time.UTC)
+	if got, err := data.GetTime("start", time.RFC3339); err != nil {
+		t.Error(err)
+	} else if !got.Equal(expected) {
+		t.Errorf("start was incorrect. Expected %s, but got %s.", expected, got)
+	}
+
+	// Empty and missing values should return the zero time and no error
+	for _, key := range []string{"blank", "missing"} {
+		if got, err := data.GetTime(key, time.RFC3339); err != nil {
+			t.Errorf("Unexpected error for %s: %s", key, err)
+		} else if !got.IsZero() {
+			t.Errorf("Expected zero time for %s but got %s.", key, got)
+		}
+	}
+
+	// A layout mismatch should surface the parse error
+	if _, err := data.GetTime("local", time.RFC3339); err == nil {
+		t.Error("Expected an error for a layout mismatch but got none.")
+	}
+
+	loc := time.FixedZone("EST", -5*60*60)
+	expected = time.Date(2015, 6, 1, 12, 30, 0, 0, loc)
+	if got, err := data.GetTimeInLocation("local", "2006-01-02 15:04", loc); err != nil {
+		t.Error(err)
+	} else if !got.Equal(expected) {
+		t.Errorf("local was incorrect. Expected %s, but got %s.", expected, got)
+	}
+}
+
+func TestGetUnixTime(t *testing.T) {
+	data := newData()
+	data.Add("seconds", "1136214245")
+	data.Add("millis", "1136214245123")
+	data.Add("negative", "-1500")
+	data.Add("invalid", "not-a-number")
+
+	expected := time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+	if got, err := data.GetUnixTime("seconds"); err != nil {
+		t.Error(err)
+	} else if !got.Equal(expected) {
+		t.Errorf("seconds was incorrect. Expected %s, but got %s.", expected, got)
+	}
+	expectedMilli := expected.Add(123 * time.Millisecond)
+	if got, err := data.GetUnixMilliTime("millis"); err != nil {
+		t.Error(err)
+	} else if !got.Equal(expectedMilli) {
+		t.Errorf("millis was incorrect. Expected %s, but got %s.", expectedMilli, got)
+	}
+	expectedNegative := time.Unix(0, 0).Add(-1500 * time.Millisecond)
+	if got, err := data.GetUnixMilliTime("negative"); err != nil {
+		t.Error(err)
+	} else if !got.Equal(expectedNegative) {
+		t.Errorf("negative was incorrect. Expected %s, but got %s.", expectedNegative, got)
+	}
+	if got, err := data.GetUnixTime("missing"); err != nil || !got.IsZero() {
+		t.Errorf("Expected zero time and nil error for missing key but got (%s, %v).", got, err)
+	}
+	if _, err := data.GetUnixTime("invalid"); err == nil {
+		t.Error("Expected an error for a non-numeric value but got none.")
+	}
+	if _, err := data.GetUnixMilliTime("invalid"); err == nil {
+		t.Error("Expected an error for a non-numeric value but got none.")
+	}
+}
+
+func TestGetDuration(t *testing.T) {
+	data := newData()
+	data.Add("interval", "1h30m")
+	data.Add("timeout", "500ms")
+	data.Add("blank", "")
+	data.Add("garbage", "soon")
 
 	table := []struct {
+		key         string
+		expected    time.Duration
+		expectError bool
+	}{
+		{key: "interval", expected: 90 * time.Minute},
+		{key: "timeout", expected: 500 * time.Millisecond},
+		{key: "blank", expected: 0},
+		{key: "missing", expected: 0},
+		{key: "garbage", expectError: true},
+	}
+	for _, test := range table {
+		got, err := data.GetDuration(test.key)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("Expected an error for %s but got none.", test.key)
+			}
+		} else if err != nil {
+			t.Errorf("Unexpected error for %s: %s", test.key, err)
+		} else if got != test.expected {
+			t.Errorf("%s was incorrect. Expected %s, but got %s.", test.key, test.expected, got)
+		}
+	}
+}
+
+func TestGetDefaults(t *testing.T) {
+	data := newData()
+	data.Add("notify", "true")
+	data.Add("public", "false")
+	data.Add("blank", "")
+	data.Add("page", "3")
+	data.Add("sort", "name")
+
+	boolTable := []struct {
 		key      string
-		expected []byte
+		def      bool
+		expected bool
 	}{
-		{
-			key:      "name",
-			expected: []byte("bob"),
-		},
-		{
-			key:      "profession",
-			expected: []byte("plumber"),
-		},
-		{
-			key:      "favoriteColor",
-			expected: []byte(""),
-		},
+		{key: "missing", def: true, expected: true},
+		{key: "blank", def: true, expected: true},
+		{key: "notify", def: false, expected: true},
+		{key: "public", def: true, expected: false},
+	}
+	for _, test := range boolTable {
+		if got := data.GetBoolDefault(test.key, test.def); got != test.expected {
+			t.Errorf("%s was incorrect. Expected %t, but got %t.\n", test.key, test.expected, got)
+		}
+	}
+
+	if got := data.GetIntDefault("page", 1); got != 3 {
+		t.Errorf("page was incorrect. Expected 3, but got %d.", got)
+	}
+	if got := data.GetIntDefault("missing", 1); got != 1 {
+		t.Errorf("Expected default of 1 for missing key but got %d.", got)
+	}
+	if got := data.GetStringDefault("sort", "date"); got != "name" {
+		t.Errorf(`sort was incorrect. Expected "name", but got "%s".`, got)
+	}
+	if got := data.GetStringDefault("blank", "date"); got != "date" {
+		t.Errorf(`Expected default of "date" for blank key but got "%s".`, got)
+	}
+}
+
+func TestGetBoolLoose(t *testing.T) {
+	table := map[string]bool{
+		"on":      true,
+		"YES":     true,
+		"y":       true,
+		"1":       true,
+		"True":    true,
+		"off":     false,
+		"No":      false,
+		"n":       false,
+		"0":       false,
+		"false":   false,
+		"garbage": false,
+		"":        false,
 	}
+	for value, expected := range table {
+		data := CreateFromMap(map[string]string{"checkbox": value})
+		if got := data.GetBoolLoose("checkbox"); got != expected {
+			t.Errorf("%q was incorrect. Expected %t, but got %t.", value, expected, got)
+		}
+	}
+	if newData().GetBoolLoose("missing") {
+		t.Error("Expected GetBoolLoose to return false for a missing key.")
+	}
+}
 
+func TestGetIntClamped(t *testing.T) {
+	data := newData()
+	data.Add("below", "-5")
+	data.Add("above", "500")
+	data.Add("inRange", "20")
+	data.Add("invalid", "twenty")
+	data.Add("blank", "")
+
+	table := []struct {
+		key      string
+		expected int
+	}{
+		{key: "below", expected: 1},
+		{key: "above", expected: 100},
+		{key: "inRange", expected: 20},
+		{key: "invalid", expected: 10},
+		{key: "blank", expected: 10},
+		{key: "missing", expected: 10},
+	}
 	for _, test := range table {
-		got := data.GetBytes(test.key)
-		if len(got) == 0 && len(test.expected) == 0 {
-			// do nothing
-			// reflect.DeepEqual doesn't like when both lengths are zero, but it should pass.
-		} else if !reflect.DeepEqual(got, test.expected) {
-			t.Errorf("%s was incorrect. Expected %v, but got %v.\n", test.key, test.expected, got)
+		if got := data.GetIntClamped(test.key, 1, 100, 10); got != test.expected {
+			t.Errorf("%s was incorrect. Expected %d, but got %d.", test.key, test.expected, got)
 		}
 	}
 }
 
-func TestCreateFromMap(t *testing.T) {
-	m := map[string]string{
-		"name":          "bob",
-		"age":           "25",
-		"favoriteColor": "fuchsia",
+func TestGetIP(t *testing.T) {
+	data := newData()
+	data.Add("v4", "192.168.0.1")
+	data.Add("v6", "2001:db8::1")
+	data.Add("invalid", "192.168.0.256")
+
+	table := []struct {
+		key         string
+		expected    net.IP
+		expectError bool
+	}{
+		{key: "v4", expected: net.ParseIP("192.168.0.1")},
+		{key: "v6", expected: net.ParseIP("2001:db8::1")},
+		{key: "missing", expected: nil},
+		{key: "invalid", expectError: true},
 	}
-	data := CreateFromMap(m)
+	for _, test := range table {
+		got, err := data.GetIP(test.key)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("Expected an error for %s but got none.", test.key)
+			}
+		} else if err != nil {
+			t.Errorf("Unexpected error for %s: %s", test.key, err)
+		} else if !got.Equal(test.expected) {
+			t.Errorf("%s was incorrect. Expected %s, but got %s.", test.key, test.expected, got)
+		}
+	}
+}
+
+func TestGetDecimal(t *testing.T) {
+	data := newData()
+	data.Add("price", "19.99")
+	data.Add("third", "1/3")
+	data.Add("word", "abc")
 
 	table := []struct {
 		key      string
-		expected string
+		expected *big.Rat
 	}{
-		{
-			key:      "name",
-			expected: "bob",
-		},
-		{
-			key:      "age",
-			expected: "25",
-		},
-		{
-			key:      "dreamJob",
-			expected: "",
+		{key: "price", expected: big.NewRat(1999, 100)},
+		{key: "third", expected: big.NewRat(1, 3)},
+	}
+	for _, test := range table {
+		got, err := data.GetDecimal(test.key)
+		if err != nil {
+			t.Errorf("Unexpected error for %s: %s", test.key, err)
+		} else if got.Cmp(test.expected) != 0 {
+			t.Errorf("%s was incorrect. Expected %s, but got %s.", test.key, test.expected, got)
+		}
+	}
+	if got, err := data.GetDecimal("missing"); err != nil || got != nil {
+		t.Errorf("Expected (nil, nil) for missing key but got (%v, %v).", got, err)
+	}
+	if _, err := data.GetDecimal("word"); err == nil {
+		t.Error("Expected an error for a malformed value but got none.")
+	}
+}
+
+func TestGetRune(t *testing.T) {
+	data := newData()
+	data.Add("letter", "A")
+	data.Add("emoji", "😀")
+	data.Add("blank", "")
+	data.Add("word", "ab")
+	data.Add("invalid", "\xff")
+	data.Add("replacement", "\uFFFD")
+
+	table := []struct {
+		key         string
+		expected    rune
+		expectError bool
+	}{
+		{key: "letter", expected: 'A'},
+		{key: "emoji", expected: '😀'},
+		{key: "replacement", expected: '\uFFFD'},
+		{key: "blank", expected: 0},
+		{key: "missing", expected: 0},
+		{key: "word", expectError: true},
+		{key: "invalid", expectError: true},
+	}
+	for _, test := range table {
+		got, err := data.GetRune(test.key)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("Expected an error for %s but got none.", test.key)
+			}
+		} else if err != nil {
+			t.Errorf("Unexpected error for %s: %s", test.key, err)
+		} else if got != test.expected {
+			t.Errorf("%s was incorrect. Expected %q, but got %q.", test.key, test.expected, got)
+		}
+	}
+}
+
+func TestGetHexColor(t *testing.T) {
+	data := newData()
+	data.Add("short", "#fff")
+	data.Add("long", "ff00aa")
+	data.Add("invalid", "#zzz")
+	data.Add("length", "#ff00a")
+
+	table := []struct {
+		key         string
+		expected    color.RGBA
+		expectError bool
+	}{
+		{key: "short", expected: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}},
+		{key: "long", expected: color.RGBA{R: 0xff, G: 0x00, B: 0xaa, A: 0xff}},
+		{key: "missing", expected: color.RGBA{}},
+		{key: "invalid", expectError: true},
+		{key: "length", expectError: true},
+	}
+	for _, test := range table {
+		got, err := data.GetHexColor(test.key)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("Expected an error for %s but got none.", test.key)
+			}
+		} else if err != nil {
+			t.Errorf("Unexpected error for %s: %s", test.key, err)
+		} else if got != test.expected {
+			t.Errorf("%s was incorrect. Expected %v, but got %v.", test.key, test.expected, got)
+		}
+	}
+}
+
+func TestGetBase64(t *testing.T) {
+	blob := []byte{0x00, 0xfb, 0xff, 0x10, 0x3e}
+	data := newData()
+	data.Add("std", base64.StdEncoding.EncodeToString(blob))
+	data.Add("url", base64.URLEncoding.EncodeToString(blob))
+	data.Add("invalid", "not base64!")
+
+	if got, err := data.GetBase64("std"); err != nil {
+		t.Error(err)
+	} else if !bytes.Equal(got, blob) {
+		t.Errorf("std was incorrect. Expected %v, but got %v.", blob, got)
+	}
+	if got, err := data.GetBase64URL("url"); err != nil {
+		t.Error(err)
+	} else if !bytes.Equal(got, blob) {
+		t.Errorf("url was incorrect. Expected %v, but got %v.", blob, got)
+	}
+	if got, err := data.GetBase64("missing"); err != nil || got != nil {
+		t.Errorf("Expected (nil, nil) for missing key but got (%v, %v).", got, err)
+	}
+	if _, err := data.GetBase64("invalid"); err == nil {
+		t.Error("Expected an error for invalid base64 but got none.")
+	}
+	if _, err := data.GetBase64("url"); err == nil {
+		t.Error("Expected an error decoding url-safe base64 with the standard alphabet but got none.")
+	}
+}
+
+func TestGetUUID(t *testing.T) {
+	data := newData()
+	data.Add("id", "123e4567-E89B-12d3-a456-426655440000")
+	data.Add("noHyphens", "123e4567e89b12d3a456426655440000")
+	data.Add("badHex", "123e4567-e89b-12d3-a456-42665544000g")
+	data.Add("misplaced", "123e45-67e89b-12d3-a456-426655440000")
+
+	expected := [16]byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x55, 0x44, 0x00, 0x00}
+	if got, err := data.GetUUID("id"); err != nil {
+		t.Error(err)
+	} else if got != expected {
+		t.Errorf("id was incorrect. Expected %x, but got %x.", expected, got)
+	}
+	if got, err := data.GetUUID("missing"); err != nil || got != [16]byte{} {
+		t.Errorf("Expected zero value and nil error for missing key but got (%x, %v).", got, err)
+	}
+	for _, key := range []string{"noHyphens", "badHex", "misplaced"} {
+		if _, err := data.GetUUID(key); err == nil {
+			t.Errorf("Expected an error for %s but got none.", key)
+		}
+	}
+}
+
+func TestBytes(t *testing.T) {
+	data := newData()
+	data.Values = map[string][]string{
+		"name":       []string{"bob", "bill"},
+		"profession": []string{"plumber"},
+	}
+
+	table := []struct {
+		key      string
+		expected []byte
+	}{
+		{
+			key:      "name",
+			expected: []byte("bob"),
+		},
+		{
+			key:      "profession",
+			expected: []byte("plumber"),
+		},
+		{
+			key:      "favoriteColor",
+			expected: []byte(""),
+		},
+	}
+
+	for _, test := range table {
+		got := data.GetBytes(test.key)
+		if len(got) == 0 && len(test.expected) == 0 {
+			// do nothing
+			// reflect.DeepEqual doesn't like when both lengths are zero, but it should pass.
+		} else if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%s was incorrect. Expected %v, but got %v.\n", test.key, test.expected, got)
+		}
+	}
+}
+
+func TestCreateFromMap(t *testing.T) {
+	m := map[string]string{
+		"name":          "bob",
+		"age":           "25",
+		"favoriteColor": "fuchsia",
+	}
+	data := CreateFromMap(m)
+
+	table := []struct {
+		key      string
+		expected string
+	}{
+		{
+			key:      "name",
+			expected: "bob",
+		},
+		{
+			key:      "age",
+			expected: "25",
+		},
+		{
+			key:      "dreamJob",
+			expected: "",
+		},
+	}
+
+	for _, test := range table {
+		got := data.Get(test.key)
+		if got != test.expected {
+			t.Errorf("%s was incorrect. Expected %s, but got %s.\n", test.key, test.expected, got)
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	original := newData()
+	original.Add("color", "blue")
+	original.Add("color", "green")
+	original.Add("name", "bob")
+
+	clone := original.Clone()
+	if !reflect.DeepEqual(clone.Values, original.Values) {
+		t.Errorf("Expected clone to equal original. Expected %v, but got %v.\n", original.Values, clone.Values)
+	}
+
+	clone.Values["color"][0] = "red"
+	clone.Values["color"] = append(clone.Values["color"], "fuchsia")
+	clone.Set("name", "bill")
+	clone.Add("age", "25")
+	expected := map[string][]string{
+		"color": []string{"blue", "green"},
+		"name":  []string{"bob"},
+	}
+	if !reflect.DeepEqual(map[string][]string(original.Values), expected) {
+		t.Errorf("Expected original to be unchanged. Expected %v, but got %v.\n", expected, original.Values)
+	}
+}
+
+func TestToURLValuesAndToMap(t *testing.T) {
+	data := newData()
+	data.Add("color", "blue")
+	data.Add("color", "green")
+	data.Add("name", "bob")
+	expected := map[string][]string{
+		"color": []string{"blue", "green"},
+		"name":  []string{"bob"},
+	}
+
+	values := data.ToURLValues()
+	if !reflect.DeepEqual(map[string][]string(values), expected) {
+		t.Errorf("Expected ToURLValues to return %v but got %v", expected, values)
+	}
+	values["color"][0] = "red"
+	values.Set("name", "bill")
+	values.Add("age", "25")
+
+	m := data.ToMap()
+	if !reflect.DeepEqual(m, expected) {
+		t.Errorf("Expected ToMap to return %v but got %v", expected, m)
+	}
+	m["color"][1] = "fuchsia"
+	delete(m, "name")
+
+	if !reflect.DeepEqual(map[string][]string(data.Values), expected) {
+		t.Errorf("Expected data to be unchanged. Expected %v, but got %v.", expected, data.Values)
+	}
+}
+
+func TestOnlyAndExcept(t *testing.T) {
+	data := newData()
+	data.Add("name", "bob")
+	data.Add("email", "bob@example.com")
+	data.Add("admin", "true")
+	original := map[string][]string{
+		"name":  []string{"bob"},
+		"email": []string{"bob@example.com"},
+		"admin": []string{"true"},
+	}
+
+	only := data.Only("name", "email", "missing")
+	expected := map[string][]string{
+		"name":  []string{"bob"},
+		"email": []string{"bob@example.com"},
+	}
+	if !reflect.DeepEqual(map[string][]string(only.Values), expected) {
+		t.Errorf("Result of Only was incorrect. Expected %v, but got %v.\n", expected, only.Values)
+	}
+
+	except := data.Except("admin")
+	if !reflect.DeepEqual(map[string][]string(except.Values), expected) {
+		t.Errorf("Result of Except was incorrect. Expected %v, but got %v.\n", expected, except.Values)
+	}
+
+	// Neither result should share memory with the original
+	only.Values["name"][0] = "bill"
+	except.Values["email"][0] = "bill@example.com"
+	if !reflect.DeepEqual(map[string][]string(data.Values), original) {
+		t.Errorf("Expected original to be unchanged. Expected %v, but got %v.\n", original, data.Values)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	defaults := newData()
+	defaults.Add("color", "blue")
+	defaults.Add("size", "medium")
+	submitted := newData()
+	submitted.Add("color", "red")
+	submitted.Add("color", "green")
+	submitted.Add("name", "bob")
+
+	defaults.Merge(submitted)
+	expected := map[string][]string{
+		"color": []string{"blue", "red", "green"},
+		"size":  []string{"medium"},
+		"name":  []string{"bob"},
+	}
+	if !reflect.DeepEqual(map[string][]string(defaults.Values), expected) {
+		t.Errorf("Result of Merge was incorrect. Expected %v, but got %v.\n", expected, defaults.Values)
+	}
+}
+
+func TestAddFromRequest(t *testing.T) {
+	data := newData()
+	data.Add("user", "alice")
+	data.Add("role", "admin")
+
+	req, err := http.NewRequest("POST", "/?page=2", strings.NewReader("user=bob&color=blue"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if err := data.AddFromRequest(req); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]string{
+		"user":  []string{"alice", "bob"},
+		"role":  []string{"admin"},
+		"color": []string{"blue"},
+		"page":  []string{"2"},
+	}
+	if !reflect.DeepEqual(map[string][]string(data.Values), expected) {
+		t.Errorf("Expected values %v but got %v", expected, data.Values)
+	}
+
+	// A request which cannot be parsed should leave data unchanged.
+	req, err = http.NewRequest("POST", "/", strings.NewReader("{"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := data.AddFromRequest(req); !errors.Is(err, ErrJSON) {
+		t.Errorf("Expected ErrJSON but got %v", err)
+	}
+	if !reflect.DeepEqual(map[string][]string(data.Values), expected) {
+		t.Errorf("Expected values to be unchanged after an error. Expected %v, but got %v", expected, data.Values)
+	}
+
+	// The json body and content length come from the request
+	body := `{"user":"carol","settings":{"theme":"dark"}}`
+	req, err = http.NewRequest("POST", "/", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := data.AddFromRequest(req); err != nil {
+		t.Fatal(err)
+	}
+	if got := data.ContentLength(); got != int64(len(body)) {
+		t.Errorf("Expected ContentLength to be %d but got %d", len(body), got)
+	}
+	var bound struct {
+		Settings struct {
+			Theme string `json:"theme"`
+		} `json:"settings"`
+	}
+	if err := data.BindJSON(&bound); err != nil {
+		t.Fatal(err)
+	}
+	if bound.Settings.Theme != "dark" {
+		t.Errorf(`Expected BindJSON to bind theme "dark" but got "%s"`, bound.Settings.Theme)
+	}
+	expected["user"] = append(expected["user"], "carol")
+	expected["settings"] = []string{`{"theme":"dark"}`}
+	if !reflect.DeepEqual(map[string][]string(data.Values), expected) {
+		t.Errorf("Expected values %v but got %v", expected, data.Values)
+	}
+
+	// Values added before an error partway through the body are removed
+	tooMany := url.Values{}
+	for i := 0; i <= DefaultMaxValuesPerKey; i++ {
+		tooMany.Add("tag", strconv.Itoa(i))
+	}
+	req, err = http.NewRequest("POST", "/", strings.NewReader("color=red&"+tooMany.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if err := data.AddFromRequest(req); !errors.Is(err, ErrTooManyValues) {
+		t.Errorf("Expected ErrTooManyValues but got %v", err)
+	}
+	if !reflect.DeepEqual(map[string][]string(data.Values), expected) {
+		t.Errorf("Expected values to be unchanged after an error. Expected %v, but got %v", expected, data.Values)
+	}
+	if got := data.ContentLength(); got != int64(len(body)) {
+		t.Errorf("Expected ContentLength to be unchanged after an error but got %d", got)
+	}
+}
+
+func TestMergeOverride(t *testing.T) {
+	defaults := newData()
+	defaults.Add("color", "blue")
+	defaults.Add("size", "medium")
+	submitted := newData()
+	submitted.Add("color", "red")
+	submitted.Add("color", "green")
+	submitted.Add("name", "bob")
+
+	defaults.MergeOverride(submitted)
+	expected := map[string][]string{
+		"color": []string{"red", "green"},
+		"size":  []string{"medium"},
+		"name":  []string{"bob"},
+	}
+	if !reflect.DeepEqual(map[string][]string(defaults.Values), expected) {
+		t.Errorf("Result of MergeOverride was incorrect. Expected %v, but got %v.\n", expected, defaults.Values)
+	}
+
+	// The merged values should not share memory with other
+	defaults.Values["color"][0] = "fuchsia"
+	if submitted.Get("color") != "red" {
+		t.Errorf("Expected MergeOverride to copy values but other was mutated: %v", submitted.Values["color"])
+	}
+}
+
+func TestSetValues(t *testing.T) {
+	data := newData()
+	data.Add("color", "fuchsia")
+	colors := []string{"red", "green", "blue"}
+	data.SetValues("color", colors)
+	if got := data.GetStrings("color"); !reflect.DeepEqual(got, colors) {
+		t.Errorf("Expected GetStrings to return %v but got %v.", colors, got)
+	}
+	if got := data.Get("color"); got != "red" {
+		t.Errorf(`Expected Get to return "red" but got "%s".`, got)
+	}
+	colors[0] = "purple"
+	if got := data.Get("color"); got != "red" {
+		t.Errorf(`Expected SetValues to copy values but Get returned "%s".`, got)
+	}
+}
+
+func TestDelAll(t *testing.T) {
+	data := newData()
+	data.Add("name", "bob")
+	data.Add("csrf_token", "abc123")
+	data.Add("_internal", "true")
+
+	if got := data.DelAll("csrf_token", "_internal", "missing"); got != 2 {
+		t.Errorf("Expected DelAll to remove 2 keys but got %d.", got)
+	}
+	expected := map[string][]string{"name": []string{"bob"}}
+	if !reflect.DeepEqual(map[string][]string(data.Values), expected) {
+		t.Errorf("Result of DelAll was incorrect. Expected %v, but got %v.\n", expected, data.Values)
+	}
+}
+
+func TestRename(t *testing.T) {
+	data := newData()
+	data.Add("user_name", "bob")
+	data.Add("user_name", "bill")
+	data.Add("mail", "bob@example.com")
+	data.Add("email", "bill@example.com")
+	avatar := &multipart.FileHeader{Filename: "avatar.png"}
+	data.AddFile("picture", avatar)
+
+	data.Rename("user_name", "username")
+	data.Rename("mail", "email")
+	data.Rename("picture", "avatar")
+	data.Rename("missing", "other")
+	data.Rename("username", "username")
+	expected := map[string][]string{
+		"username": []string{"bob", "bill"},
+		"email":    []string{"bill@example.com", "bob@example.com"},
+	}
+	if !reflect.DeepEqual(map[string][]string(data.Values), expected) {
+		t.Errorf("Result of Rename was incorrect. Expected %v, but got %v.", expected, data.Values)
+	}
+	if data.FileExists("picture") || data.GetFile("avatar") != avatar {
+		t.Errorf("Expected file to be moved from picture to avatar but got files %v", data.Files)
+	}
+	if data.KeyExists("other") {
+		t.Error("Expected Rename of a missing key not to add the new key.")
+	}
+}
+
+func TestFromValuesAndFromMap(t *testing.T) {
+	values := url.Values{"name": []string{"bob"}, "age": []string{"25"}}
+	data := FromValues(values)
+	if got := data.Get("name"); got != "bob" {
+		t.Errorf(`Expected name to be "bob" but got "%s"`, got)
+	}
+	if got := data.GetInt("age"); got != 25 {
+		t.Errorf("Expected age to be 25 but got %d", got)
+	}
+
+	m := map[string][]string{"color": []string{"red", "green"}}
+	data = FromMap(m)
+	if got := data.GetStrings("color"); !reflect.DeepEqual(got, []string{"red", "green"}) {
+		t.Errorf("Expected color to be [red green] but got %v", got)
+	}
+	// Changes to the map should not leak into data
+	m["color"][0] = "blue"
+	m["size"] = []string{"large"}
+	if got := data.Get("color"); got != "red" {
+		t.Errorf(`Expected color to still be "red" but got "%s"`, got)
+	}
+	if data.KeyExists("size") {
+		t.Error("Expected size to not exist but it did.")
+	}
+}
+
+func TestGetStringsSplit(t *testing.T) {
+	data := newData()
+	data.Values = map[string][]string{
+		"children":       []string{"martha,bill,jane", "adam,julia"},
+		"favoriteColors": []string{"blue%20green%20fuchsia"},
+	}
+
+	table := []struct {
+		key       string
+		delim     string
+		expecteds []string
+	}{
+		{
+			key:       "children",
+			delim:     ",",
+			expecteds: []string{"martha", "bill", "jane"},
+		},
+		{
+			key:       "favoriteColors",
+			delim:     "%20",
+			expecteds: []string{"blue", "green", "fuchsia"},
+		},
+		{
+			key:       "height",
+			delim:     "-",
+			expecteds: []string{},
+		},
+	}
+
+	for _, test := range table {
+		gots := data.GetStringsSplit(test.key, test.delim)
+		if len(gots) == 0 && len(test.expecteds) == 0 {
+			// do nothing
+			// reflect.DeepEqual doesn't like when both lengths are zero, but it should pass.
+		} else if !reflect.DeepEqual(gots, test.expecteds) {
+			t.Errorf("%s was incorrect. Expected %v, but got %v.\n", test.key, test.expecteds, gots)
+		}
+	}
+}
+
+func TestGetStrings(t *testing.T) {
+	data := newData()
+	data.Values = map[string][]string{
+		"colors":  []string{"red", "green", "blue"},
+		"name":    []string{"bob"},
+		"hobbies": []string{},
+	}
+
+	table := []struct {
+		key       string
+		expecteds []string
+	}{
+		{
+			key:       "colors",
+			expecteds: []string{"red", "green", "blue"},
+		},
+		{
+			key:       "name",
+			expecteds: []string{"bob"},
+		},
+		{
+			key:       "hobbies",
+			expecteds: []string{},
+		},
+		{
+			key:       "height",
+			expecteds: nil,
+		},
+	}
+
+	for _, test := range table {
+		gots := data.GetStrings(test.key)
+		if len(gots) == 0 && len(test.expecteds) == 0 {
+			// do nothing
+			// reflect.DeepEqual doesn't like when both lengths are zero, but it should pass.
+		} else if !reflect.DeepEqual(gots, test.expecteds) {
+			t.Errorf("%s was incorrect. Expected %v, but got %v.\n", test.key, test.expecteds, gots)
+		}
+	}
+	if gots := data.GetStrings("height"); gots != nil {
+		t.Errorf("Expected nil for missing key but got %v.", gots)
+	}
+}
+
+func TestGetInts(t *testing.T) {
+	data := newData()
+	data.Values = map[string][]string{
+		"ids":    []string{"1", "2", "3"},
+		"badIds": []string{"1", "two", "3"},
+	}
+
+	if got, err := data.GetInts("ids"); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Errorf("ids was incorrect. Expected [1 2 3], but got %v.", got)
+	}
+	if got, err := data.GetInts("missing"); err != nil || got != nil {
+		t.Errorf("Expected (nil, nil) for missing key but got (%v, %v).", got, err)
+	}
+	if _, err := data.GetInts("badIds"); err == nil {
+		t.Error("Expected an error for badIds but got none.")
+	} else if !strings.Contains(err.Error(), "badIds[1]") || !strings.Contains(err.Error(), "two") {
+		t.Errorf("Expected error to identify the offending value but got: %s", err)
+	}
+}
+
+func TestGetFloats(t *testing.T) {
+	data := newData()
+	data.Values = map[string][]string{
+		"coords":    []string{"52.52", "13.405"},
+		"badCoords": []string{"52.52", "north"},
+	}
+
+	if got, err := data.GetFloats("coords"); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(got, []float64{52.52, 13.405}) {
+		t.Errorf("coords was incorrect. Expected [52.52 13.405], but got %v.", got)
+	}
+	if got, err := data.GetFloats("missing"); err != nil || got != nil {
+		t.Errorf("Expected (nil, nil) for missing key but got (%v, %v).", got, err)
+	}
+	if _, err := data.GetFloats("badCoords"); err == nil {
+		t.Error("Expected an error for badCoords but got none.")
+	} else if !strings.Contains(err.Error(), "badCoords[1]") || !strings.Contains(err.Error(), "north") {
+		t.Errorf("Expected error to identify the offending value but got: %s", err)
+	}
+}
+
+func TestGetCSV(t *testing.T) {
+	data := newData()
+	data.Add("list", `a,"b,c",d`)
+	data.Add("malformed", `a,"b,c`)
+	data.Add("multiline", "a,b\nc,d")
+
+	expected := []string{"a", "b,c", "d"}
+	if got, err := data.GetCSV("list"); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(got, expected) {
+		t.Errorf("list was incorrect. Expected %v, but got %v.", expected, got)
+	}
+	if got, err := data.GetCSV("missing"); err != nil || got != nil {
+		t.Errorf("Expected (nil, nil) for missing key but got (%v, %v).", got, err)
+	}
+	if _, err := data.GetCSV("malformed"); err == nil {
+		t.Error("Expected an error for malformed csv but got none.")
+	}
+	if _, err := data.GetCSV("multiline"); err == nil {
+		t.Error("Expected an error for more than one record but got none.")
+	}
+}
+
+func TestGetIntsSplit(t *testing.T) {
+	data := newData()
+	data.Add("ids", "10-20-30")
+	data.Add("spaced", "1 | 2 | 3")
+	data.Add("invalid", "1-x-3")
+	data.Add("empty", "")
+
+	if got, err := data.GetIntsSplit("ids", "-"); err != nil {
+		t.Error(err)
+	} else if expected := []int{10, 20, 30}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("ids was incorrect. Expected %v, but got %v.", expected, got)
+	}
+	if got, err := data.GetIntsSplit("spaced", "|"); err != nil {
+		t.Error(err)
+	} else if expected := []int{1, 2, 3}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("spaced was incorrect. Expected %v, but got %v.", expected, got)
+	}
+	if _, err := data.GetIntsSplit("invalid", "-"); err == nil {
+		t.Error("Expected an error for a non-integer element but got none.")
+	} else if !strings.Contains(err.Error(), `"x"`) {
+		t.Errorf("Expected error to name the bad element but got: %s", err)
+	}
+	for _, key := range []string{"missing", "empty"} {
+		if got, err := data.GetIntsSplit(key, "-"); err != nil || got != nil {
+			t.Errorf("Expected (nil, nil) for %s but got (%v, %v).", key, got, err)
+		}
+	}
+}
+
+func TestGetStringList(t *testing.T) {
+	data := newData()
+	data.Add("tags", "a, b ,c,")
+	data.Add("commas", " , ,")
+
+	if got := data.GetStringList("tags"); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("tags was incorrect. Expected [a b c], but got %v.", got)
+	}
+	if got := data.GetStringList("commas"); len(got) != 0 {
+		t.Errorf("Expected no elements for commas but got %v.", got)
+	}
+	if got := data.GetStringList("missing"); got != nil {
+		t.Errorf("Expected nil for missing key but got %v.", got)
+	}
+}
+
+func TestParseUrlEncoded(t *testing.T) {
+	// Construct a urlencoded form request
+	// Add some simple key-value params to the form
+	fieldData := map[string]string{
+		"name":           "Bob",
+		"age":            "25",
+		"favoriteNumber": "99.99",
+		"leftHanded":     "true",
+	}
+	values := url.Values{}
+	for fieldname, value := range fieldData {
+		values.Add(fieldname, value)
+	}
+	req, err := http.NewRequest("POST", "/", strings.NewReader(values.Encode()))
+	if err != nil {
+		t.Error(err)
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	// Parse the request
+	d, err := Parse(req)
+	if err != nil {
+		t.Error(err)
+	}
+	testBasicFormFields(t, d)
+}
+
+func TestParseUrlEncodedMethods(t *testing.T) {
+	for _, method := range []string{"PUT", "PATCH", "DELETE"} {
+		values := url.Values{}
+		values.Add("name", "Bob")
+		req, err := http.NewRequest(method, "/", strings.NewReader(values.Encode()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		d, err := Parse(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := d.Get("name"); got != "Bob" {
+			t.Errorf(`Expected name to be "Bob" for %s request but got "%s"`, method, got)
+		}
+	}
+}
+
+func TestParseRepeatedKeys(t *testing.T) {
+	// Construct a urlencoded form request with repeated keys in both the
+	// body and the url query
+	values := url.Values{}
+	values.Add("color", "red")
+	values.Add("color", "green")
+	req, err := http.NewRequest("POST", "/?color=blue&color=fuchsia", strings.NewReader(values.Encode()))
+	if err != nil {
+		t.Error(err)
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	// Parse the request
+	d, err := Parse(req)
+	if err != nil {
+		t.Error(err)
+	}
+
+	// Body values should come first, followed by query values, each
+	// in the order they were provided
+	expected := []string{"red", "green", "blue", "fuchsia"}
+	if got := d.Values["color"]; !reflect.DeepEqual(got, expected) {
+		t.Errorf("color was incorrect. Expected %v, but got %v.\n", expected, got)
+	}
+}
+
+func TestParseQueryAndParseBody(t *testing.T) {
+	newRequest := func() *http.Request {
+		values := url.Values{}
+		values.Add("name", "Bob")
+		req, err := http.NewRequest("POST", "/?page=2", strings.NewReader(values.Encode()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		return req
+	}
+
+	query := ParseQuery(newRequest())
+	expected := map[string][]string{"page": []string{"2"}}
+	if !reflect.DeepEqual(map[string][]string(query.Values), expected) {
+		t.Errorf("Result of ParseQuery was incorrect. Expected %v, but got %v.\n", expected, query.Values)
+	}
+
+	body, err := ParseBody(newRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = map[string][]string{"name": []string{"Bob"}}
+	if !reflect.DeepEqual(map[string][]string(body.Values), expected) {
+		t.Errorf("Result of ParseBody was incorrect. Expected %v, but got %v.\n", expected, body.Values)
+	}
+}
+
+func TestParseMultipart(t *testing.T) {
+	// Construct a multipart request
+	body := bytes.NewBuffer([]byte{})
+	form := multipart.NewWriter(body)
+
+	// Add some simple key-value params to the form
+	fieldData := map[string]string{
+		"name":           "Bob",
+		"age":            "25",
+		"favoriteNumber": "99.99",
+		"leftHanded":     "true",
+	}
+	for fieldname, value := range fieldData {
+		if err := form.WriteField(fieldname, value); err != nil {
+			panic(err)
+		}
+	}
+
+	// Add a file to the form
+	testFile, err := os.Open("test_file.txt")
+	if err != nil {
+		t.Error(err)
+	}
+	defer testFile.Close()
+	fileWriter, err := form.CreateFormFile("file", "test_file.txt")
+	if err != nil {
+		panic(err)
+	}
+	if _, err := io.Copy(fileWriter, testFile); err != nil {
+		panic(err)
+	}
+	// Close the form to finish writing
+	if err := form.Close(); err != nil {
+		panic(err)
+	}
+
+	req, err := http.NewRequest("POST", "/", body)
+	if err != nil {
+		t.Error(err)
+	}
+	req.Header.Add("Content-Type", "multipart/form-data; boundary="+form.Boundary())
+
+	// Parse the request
+	d, err := Parse(req)
+	if err != nil {
+		t.Error(err)
+	}
+	testBasicFormFields(t, d)
+
+	// Next test that the file was parsed correctly
+	if !d.FileExists("file") {
+		t.Error("Expected FileExists() to return true but it returned false.")
+	}
+	header := d.GetFile("file")
+	if header == nil {
+		t.Error("Exected GetFile() to return a *multipart.FileHeader but got nil.")
+	}
+	if header.Filename != "test_file.txt" {
+		t.Errorf(`Expected header.Filename to equal "test_file.txt" but got %s`, header.Filename)
+	}
+	file, err := header.Open()
+	if err != nil {
+		t.Error(err)
+	}
+	gotBytes, err := ioutil.ReadAll(file)
+	if err != nil {
+		t.Error(err)
+	}
+	if string(gotBytes) != "Hello!" {
+		t.Errorf(`Expected file contents when read directly to be "Hello!" but got %s`, string(gotBytes))
+	}
+	gotBytes, err = d.GetFileBytes("file")
+	if err != nil {
+		t.Error(err)
+	}
+	if string(gotBytes) != "Hello!" {
+		t.Errorf(`Expected GetFileBytes("file") to return "Hello!" but got %s`, string(gotBytes))
+	}
+}
+
+func TestParseMultipartMultipleFiles(t *testing.T) {
+	// Construct a multipart request with two files for the same key and a
+	// text field
+	body := bytes.NewBuffer([]byte{})
+	form := multipart.NewWriter(body)
+	if err := form.WriteField("name", "Bob"); err != nil {
+		t.Fatal(err)
+	}
+	for _, filename := range []string{"first.txt", "second.txt"} {
+		fileWriter, err := form.CreateFormFile("files", filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fileWriter.Write([]byte("contents of " + filename)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := form.Close(); err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest("POST", "/", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Content-Type", "multipart/form-data; boundary="+form.Boundary())
+	d, err := Parse(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Get("name"); got != "Bob" {
+		t.Errorf(`Expected name to be "Bob" but got "%s"`, got)
+	}
+
+	checkFiles := func(d *Data, key string) {
+		files := d.FileHeaders(key)
+		if len(files) != 2 {
+			t.Fatalf("Expected 2 files for %s but got %d", key, len(files))
+		}
+		for i, expected := range []string{"first.txt", "second.txt"} {
+			if files[i].Filename != expected {
+				t.Errorf("Expected file %d to be %s but got %s", i, expected, files[i].Filename)
+			}
+		}
+		if files[0] != d.GetFile(key) {
+			t.Errorf("Expected the first file to be the same as GetFile")
+		}
+	}
+	checkFiles(d, "files")
+	file, err := d.File("files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Filename != "first.txt" {
+		t.Errorf("Expected File to return first.txt but got %s", file.Filename)
+	}
+	if _, err := d.File("name"); err != ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound for a key without a file but got %v", err)
+	}
+	if files := d.FileHeaders("missing"); files != nil {
+		t.Errorf("Expected nil for a missing key but got %v", files)
+	}
+
+	// Every file is kept by Clone and Rename
+	clone := d.Clone()
+	checkFiles(clone, "files")
+	clone.Rename("files", "uploads")
+	checkFiles(clone, "uploads")
+	if clone.FileExists("files") {
+		t.Errorf("Expected files to be deleted by Rename")
+	}
+
+	// AddFile replaces every file for the key
+	d.AddFile("files", file)
+	if files := d.FileHeaders("files"); len(files) != 1 {
+		t.Errorf("Expected 1 file after AddFile but got %d", len(files))
+	}
+}
+
+func TestParseMultipartSharedKey(t *testing.T) {
+	// Construct a multipart request which uses the same name for
+	// text fields and a file
+	body := bytes.NewBuffer([]byte{})
+	form := multipart.NewWriter(body)
+	if err := form.WriteField("attachment", "first caption"); err != nil {
+		t.Fatal(err)
+	}
+	fileWriter, err := form.CreateFormFile("attachment", "test_file.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fileWriter.Write([]byte("Hello!")); err != nil {
+		t.Fatal(err)
+	}
+	if err := form.WriteField("attachment", "second caption"); err != nil {
+		t.Fatal(err)
+	}
+	if err := form.Close(); err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest("POST", "/", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Content-Type", "multipart/form-data; boundary="+form.Boundary())
+
+	d, err := Parse(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"first caption", "second caption"}
+	if got := d.GetStrings("attachment"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected text values %v but got %v", expected, got)
+	}
+	if !d.FileExists("attachment") {
+		t.Fatal("Expected FileExists() to return true but it returned false.")
+	}
+	gotBytes, err := d.GetFileBytes("attachment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(gotBytes) != "Hello!" {
+		t.Errorf(`Expected GetFileBytes("attachment") to return "Hello!" but got %s`, string(gotBytes))
+	}
+}
+
+func TestParseWithOptionsMaxMemory(t *testing.T) {
+	// Construct a multipart request with a field and a file which are
+	// both larger than MaxMemory
+	body := bytes.NewBuffer([]byte{})
+	form := multipart.NewWriter(body)
+	largeValue := strings.Repeat("a", 10000)
+	if err := form.WriteField("large", largeValue); err != nil {
+		t.Fatal(err)
+	}
+	fileWriter, err := form.CreateFormFile("file", "large_file.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fileWriter.Write([]byte(largeValue)); err != nil {
+		t.Fatal(err)
+	}
+	if err := form.Close(); err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest("POST", "/", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Content-Type", "multipart/form-data; boundary="+form.Boundary())
+
+	d, err := ParseWithOptions(req, Options{MaxMemory: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer req.MultipartForm.RemoveAll()
+	if got := d.Get("large"); got != largeValue {
+		t.Errorf("Expected large field to have %d characters but got %d.", len(largeValue), len(got))
+	}
+	gotBytes, err := d.GetFileBytes("file")
+	if err != nil {
+		t.Error(err)
+	}
+	if string(gotBytes) != largeValue {
+		t.Errorf("Expected large file to have %d bytes but got %d.", len(largeValue), len(gotBytes))
+	}
+}
+
+func TestParseWithOptionsTrimSpace(t *testing.T) {
+	newRequest := func() *http.Request {
+		values := url.Values{}
+		values.Add("greeting", "  hi  ")
+		req, err := http.NewRequest("POST", "/?name=%20bob%09", strings.NewReader(values.Encode()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		return req
+	}
+
+	d, err := Parse(newRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Get("greeting"); got != "  hi  " {
+		t.Errorf(`Expected greeting to be "  hi  " without TrimSpace but got "%s"`, got)
+	}
+
+	d, err = ParseWithOptions(newRequest(), Options{TrimSpace: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Get("greeting"); got != "hi" {
+		t.Errorf(`Expected greeting to be "hi" with TrimSpace but got "%s"`, got)
+	}
+	if got := d.Get("name"); got != "bob" {
+		t.Errorf(`Expected name to be "bob" with TrimSpace but got "%s"`, got)
+	}
+}
+
+func TestParseWithOptionsNormalize(t *testing.T) {
+	// Construct a multipart request, since it has both body and query values
+	body := bytes.NewBuffer([]byte{})
+	form := multipart.NewWriter(body)
+	if err := form.WriteField("name", " bob "); err != nil {
+		t.Fatal(err)
+	}
+	if err := form.Close(); err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest("POST", "/?color=red", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Content-Type", "multipart/form-data; boundary="+form.Boundary())
+
+	opts := Options{
+		TrimSpace: true,
+		Normalize: func(val string) string {
+			return "<" + strings.ToUpper(val) + ">"
 		},
 	}
+	d, err := ParseWithOptions(req, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Get("name"); got != "<BOB>" {
+		t.Errorf(`Expected name to be "<BOB>" but got "%s"`, got)
+	}
+	if got := d.Get("color"); got != "<RED>" {
+		t.Errorf(`Expected color to be "<RED>" but got "%s"`, got)
+	}
+}
 
-	for _, test := range table {
-		got := data.Get(test.key)
-		if got != test.expected {
-			t.Errorf("%s was incorrect. Expected %s, but got %s.\n", test.key, test.expected, got)
+func TestParseWithOptionsLimits(t *testing.T) {
+	newRequest := func(query string) *http.Request {
+		values := url.Values{}
+		values.Add("a", "1")
+		values.Add("b", "2")
+		values.Add("b", "3")
+		req, err := http.NewRequest("POST", "/?"+query, strings.NewReader(values.Encode()))
+		if err != nil {
+			t.Fatal(err)
 		}
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		return req
+	}
+
+	// Under the limits
+	if _, err := ParseWithOptions(newRequest("c=4"), Options{MaxKeys: 3, MaxValuesPerKey: 2}); err != nil {
+		t.Errorf("Expected no error under the limits but got: %s", err)
+	}
+
+	// Too many keys, including keys from the query
+	if _, err := ParseWithOptions(newRequest("c=4&d=5"), Options{MaxKeys: 3}); !errors.Is(err, ErrTooManyKeys) {
+		t.Errorf("Expected ErrTooManyKeys but got: %v", err)
+	}
+
+	// Too many values for a single key
+	if _, err := ParseWithOptions(newRequest(""), Options{MaxValuesPerKey: 1}); !errors.Is(err, ErrTooManyValues) {
+		t.Errorf("Expected ErrTooManyValues but got: %v", err)
+	}
+
+	// Negative limits mean no limit
+	if _, err := ParseWithOptions(newRequest("c=4&d=5"), Options{MaxKeys: -1, MaxValuesPerKey: -1}); err != nil {
+		t.Errorf("Expected no error with negative limits but got: %s", err)
+	}
+
+	// The default limits should apply to Parse
+	query := url.Values{}
+	for i := 0; i <= DefaultMaxKeys; i++ {
+		query.Add(strconv.Itoa(i), "x")
+	}
+	if _, err := Parse(newRequest(query.Encode())); !errors.Is(err, ErrTooManyKeys) {
+		t.Errorf("Expected ErrTooManyKeys with the default limits but got: %v", err)
 	}
 }
 
-func TestGetStringsSplit(t *testing.T) {
-	data := newData()
-	data.Values = map[string][]string{
-		"children":       []string{"martha,bill,jane", "adam,julia"},
-		"favoriteColors": []string{"blue%20green%20fuchsia"},
+func TestParseWithOptionsKeepRawBody(t *testing.T) {
+	body := `{"event":"push","repo":"forms"}`
+	req, err := http.NewRequest("POST", "/", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	d, err := ParseWithOptions(req, Options{KeepRawBody: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(d.RawBody()); got != body {
+		t.Errorf("Expected RawBody to return %s but got %s", body, got)
+	}
+	if got := d.Get("event"); got != "push" {
+		t.Errorf(`Expected event to be "push" but got "%s"`, got)
 	}
 
-	table := []struct {
-		key       string
-		delim     string
-		expecteds []string
-	}{
-		{
-			key:       "children",
-			delim:     ",",
-			expecteds: []string{"martha", "bill", "jane"},
-		},
-		{
-			key:       "favoriteColors",
-			delim:     "%20",
-			expecteds: []string{"blue", "green", "fuchsia"},
-		},
-		{
-			key:       "height",
-			delim:     "-",
-			expecteds: []string{},
-		},
+	// Without KeepRawBody, the body is not kept
+	req, err = http.NewRequest("POST", "/", strings.NewReader("name=bob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	d, err = Parse(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.RawBody() != nil {
+		t.Errorf("Expected RawBody to return nil without KeepRawBody but got %s", d.RawBody())
 	}
 
-	for _, test := range table {
-		gots := data.GetStringsSplit(test.key, test.delim)
-		if len(gots) == 0 && len(test.expecteds) == 0 {
-			// do nothing
-			// reflect.DeepEqual doesn't like when both lengths are zero, but it should pass.
-		} else if !reflect.DeepEqual(gots, test.expecteds) {
-			t.Errorf("%s was incorrect. Expected %v, but got %v.\n", test.key, test.expecteds, gots)
-		}
+	// The raw body is limited to MaxBodySize, but not to MaxMemory
+	req, err = http.NewRequest("POST", "/", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if _, err := ParseWithOptions(req, Options{KeepRawBody: true, MaxMemory: 8}); err != nil {
+		t.Errorf("Expected no error with a small MaxMemory but got %v", err)
+	}
+	req, err = http.NewRequest("POST", "/", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if _, err := ParseWithOptions(req, Options{KeepRawBody: true, MaxBodySize: 8}); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("Expected ErrBodyTooLarge but got %v", err)
 	}
 }
 
-func TestParseUrlEncoded(t *testing.T) {
-	// Construct a urlencoded form request
-	// Add some simple key-value params to the form
-	fieldData := map[string]string{
-		"name":           "Bob",
-		"age":            "25",
-		"favoriteNumber": "99.99",
-		"leftHanded":     "true",
+func TestParseWithOptionsDefaults(t *testing.T) {
+	req, err := http.NewRequest("POST", "/?name=bob&nickname=", nil)
+	if err != nil {
+		t.Fatal(err)
 	}
-	values := url.Values{}
-	for fieldname, value := range fieldData {
-		values.Add(fieldname, value)
+	opts := Options{
+		Defaults: map[string]string{
+			"name":      "anonymous",
+			"nickname":  "none",
+			"subscribe": "false",
+		},
+	}
+	d, err := ParseWithOptions(req, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]string{
+		"name":      []string{"bob"},
+		"nickname":  []string{""},
+		"subscribe": []string{"false"},
+	}
+	if !reflect.DeepEqual(map[string][]string(d.Values), expected) {
+		t.Errorf("Expected values %v but got %v", expected, d.Values)
 	}
+}
+
+// cancelingReader calls cancel after the first call to Read.
+type cancelingReader struct {
+	r      io.Reader
+	cancel func()
+}
+
+func (cr *cancelingReader) Read(p []byte) (int, error) {
+	if len(p) > 8 {
+		// only read a small chunk at a time
+		p = p[:8]
+	}
+	n, err := cr.r.Read(p)
+	cr.cancel()
+	return n, err
+}
+
+func TestParseContext(t *testing.T) {
+	values := url.Values{}
+	values.Add("name", "Bob")
+	values.Add("bio", strings.Repeat("a", 1000))
+
+	// A context which is never cancelled should parse normally
 	req, err := http.NewRequest("POST", "/", strings.NewReader(values.Encode()))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-
-	// Parse the request
-	d, err := Parse(req)
+	d, err := ParseContext(context.Background(), req)
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
+	}
+	if got := d.Get("name"); got != "Bob" {
+		t.Errorf(`Expected name to be "Bob" but got "%s"`, got)
 	}
-	testBasicFormFields(t, d)
-}
 
-func TestParseMultipart(t *testing.T) {
-	// Construct a multipart request
-	body := bytes.NewBuffer([]byte{})
-	form := multipart.NewWriter(body)
+	// Cancel the context in the middle of reading the body
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	body := &cancelingReader{r: strings.NewReader(values.Encode()), cancel: cancel}
+	req, err = http.NewRequest("POST", "/", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	if _, err := ParseContext(ctx, req); err != context.Canceled {
+		t.Errorf("Expected context.Canceled but got: %v", err)
+	}
 
-	// Add some simple key-value params to the form
-	fieldData := map[string]string{
-		"name":           "Bob",
-		"age":            "25",
-		"favoriteNumber": "99.99",
-		"leftHanded":     "true",
+	// The original body is restored, so it can still be read once the
+	// context is done
+	if _, ok := req.Body.(*contextReader); ok {
+		t.Errorf("Expected req.Body to be restored but it was still wrapped")
 	}
-	for fieldname, value := range fieldData {
-		if err := form.WriteField(fieldname, value); err != nil {
-			panic(err)
-		}
+	if _, err := ioutil.ReadAll(req.Body); err != nil {
+		t.Errorf("Expected the rest of the body to be readable but got: %v", err)
 	}
 
-	// Add a file to the form
-	testFile, err := os.Open("test_file.txt")
+	// Options are respected by ParseContextWithOptions
+	req, err = http.NewRequest("POST", "/", strings.NewReader(values.Encode()))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
-	defer testFile.Close()
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	if _, err := ParseContextWithOptions(context.Background(), req, Options{MaxBodySize: 100}); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("Expected ErrBodyTooLarge but got: %v", err)
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	body := bytes.NewBuffer([]byte{})
+	form := multipart.NewWriter(body)
 	fileWriter, err := form.CreateFormFile("file", "test_file.txt")
 	if err != nil {
-		panic(err)
+		t.Fatal(err)
 	}
-	if _, err := io.Copy(fileWriter, testFile); err != nil {
-		panic(err)
+	if _, err := fileWriter.Write([]byte("Hello!")); err != nil {
+		t.Fatal(err)
 	}
-	// Close the form to finish writing
 	if err := form.Close(); err != nil {
-		panic(err)
+		t.Fatal(err)
 	}
-
 	req, err := http.NewRequest("POST", "/", body)
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	req.Header.Add("Content-Type", "multipart/form-data; boundary="+form.Boundary())
-
-	// Parse the request
 	d, err := Parse(req)
 	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := d.ReadFile("file", 6); err != nil {
 		t.Error(err)
+	} else if string(got) != "Hello!" {
+		t.Errorf(`Expected ReadFile("file", 6) to return "Hello!" but got %s`, string(got))
 	}
-	testBasicFormFields(t, d)
+	if _, err := d.ReadFile("file", 5); err != ErrFileTooLarge {
+		t.Errorf("Expected ErrFileTooLarge but got %v", err)
+	}
+	if _, err := d.ReadFile("missing", 1024); err != ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound but got %v", err)
+	}
+}
 
-	// Next test that the file was parsed correctly
-	if !d.FileExists("file") {
-		t.Error("Expected FileExists() to return true but it returned false.")
+func TestFileContentType(t *testing.T) {
+	// Construct a multipart request with a file part that declares
+	// its own content type
+	body := bytes.NewBuffer([]byte{})
+	form := multipart.NewWriter(body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="avatar"; filename="avatar.png"`)
+	header.Set("Content-Type", "image/png")
+	partWriter, err := form.CreatePart(header)
+	if err != nil {
+		t.Fatal(err)
 	}
-	header := d.GetFile("file")
-	if header == nil {
-		t.Error("Exected GetFile() to return a *multipart.FileHeader but got nil.")
+	if _, err := partWriter.Write([]byte("not really a png")); err != nil {
+		t.Fatal(err)
 	}
-	if header.Filename != "test_file.txt" {
-		t.Errorf(`Expected header.Filename to equal "test_file.txt" but got %s`, header.Filename)
+	if err := form.Close(); err != nil {
+		t.Fatal(err)
 	}
-	file, err := header.Open()
+	req, err := http.NewRequest("POST", "/", body)
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
-	gotBytes, err := ioutil.ReadAll(file)
+	req.Header.Add("Content-Type", "multipart/form-data; boundary="+form.Boundary())
+
+	d, err := Parse(req)
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
-	if string(gotBytes) != "Hello!" {
-		t.Errorf(`Expected file contents when read directly to be "Hello!" but got %s`, string(gotBytes))
+	if got := d.FileContentType("avatar"); got != "image/png" {
+		t.Errorf(`Expected FileContentType("avatar") to return "image/png" but got "%s"`, got)
 	}
-	gotBytes, err = d.GetFileBytes("file")
-	if err != nil {
-		t.Error(err)
+	if got := d.GetFile("avatar").Filename; got != "avatar.png" {
+		t.Errorf(`Expected Filename to be "avatar.png" but got "%s"`, got)
 	}
-	if string(gotBytes) != "Hello!" {
-		t.Errorf(`Expected GetFileBytes("file") to return "Hello!" but got %s`, string(gotBytes))
+	if got := d.FileContentType("missing"); got != "" {
+		t.Errorf(`Expected FileContentType("missing") to return "" but got "%s"`, got)
 	}
 }
 
@@ -518,6 +2273,193 @@ func TestParseJSON(t *testing.T) {
 	}
 }
 
+func TestParseJSONMalformed(t *testing.T) {
+	body := bytes.NewBuffer([]byte(`{"name": "bob",`))
+	req, err := http.NewRequest("POST", "/", body)
+	if err != nil {
+		t.Error(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if _, err := Parse(req); err == nil {
+		t.Error("Expected an error for a malformed json body but got none.")
+	}
+}
+
+func TestParseJSONWithQuery(t *testing.T) {
+	// Flat json values and url query parameters should both be accessible
+	// through Get, with the json body taking priority.
+	body := bytes.NewBuffer([]byte(`{"name": "bob", "ids": [1, 2, 3]}`))
+	req, err := http.NewRequest("POST", "/?name=bill&page=2", body)
+	if err != nil {
+		t.Error(err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	d, err := Parse(req)
+	if err != nil {
+		t.Error(err)
+	}
+	if got := d.Get("name"); got != "bob" {
+		t.Errorf(`Expected name to be "bob" but got "%s"`, got)
+	}
+	if got := d.Values["name"]; !reflect.DeepEqual(got, []string{"bob", "bill"}) {
+		t.Errorf(`Expected name values to be [bob bill] but got %v`, got)
+	}
+	if got := d.GetInt("page"); got != 2 {
+		t.Errorf("Expected page to be 2 but got %d", got)
+	}
+	// Arrays are kept as a json string so they can be unmarshaled by the caller
+	if got, err := d.GetSliceFromJSON("ids"); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(got, []interface{}{1.0, 2.0, 3.0}) {
+		t.Errorf("Expected ids to be [1 2 3] but got %v", got)
+	}
+}
+
+func TestGetJSON(t *testing.T) {
+	data := newData()
+	data.Add("location", `{"latitude": 123.456, "longitude": 948.123}`)
+	data.Add("invalid", `{"latitude":`)
+	data.Add("blank", "")
+
+	type location struct {
+		Latitude  float64 `json:"latitude"`
+		Longitude float64 `json:"longitude"`
+	}
+	expected := location{Latitude: 123.456, Longitude: 948.123}
+	got := location{}
+	if err := data.GetJSON("location", &got); err != nil {
+		t.Error(err)
+	} else if got != expected {
+		t.Errorf("location was incorrect. Expected %+v, but got %+v.\n", expected, got)
+	}
+
+	expectedMap := map[string]interface{}{"latitude": 123.456, "longitude": 948.123}
+	gotMap := map[string]interface{}{}
+	if err := data.GetJSON("location", &gotMap); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(gotMap, expectedMap) {
+		t.Errorf("location was incorrect. Expected %v, but got %v.\n", expectedMap, gotMap)
+	}
+
+	for _, key := range []string{"missing", "blank"} {
+		if err := data.GetJSON(key, &gotMap); err != ErrKeyNotFound {
+			t.Errorf("Expected ErrKeyNotFound for %s but got %v.", key, err)
+		}
+	}
+	if err := data.GetJSON("invalid", &gotMap); err == nil {
+		t.Error("Expected an error for invalid json but got none.")
+	} else if err == ErrKeyNotFound {
+		t.Error("Expected a json error for invalid json but got ErrKeyNotFound.")
+	}
+}
+
+func TestParsePlainText(t *testing.T) {
+	newRequest := func(body string) *http.Request {
+		req, err := http.NewRequest("POST", "/?source=webhook", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+		return req
+	}
+
+	d, err := Parse(newRequest("Hello, world!\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Get("_body"); got != "Hello, world!\n" {
+		t.Errorf(`Expected _body to be "Hello, world!\n" but got "%s"`, got)
+	}
+	if got := d.Get("source"); got != "webhook" {
+		t.Errorf(`Expected source to be "webhook" but got "%s"`, got)
+	}
+
+	d, err = ParseWithOptions(newRequest("Hello!"), Options{BodyKey: "message"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Get("message"); got != "Hello!" {
+		t.Errorf(`Expected message to be "Hello!" but got "%s"`, got)
+	}
+
+	if _, err := ParseWithOptions(newRequest("Hello, world!"), Options{MaxBodySize: 5}); err != ErrBodyTooLarge {
+		t.Errorf("Expected ErrBodyTooLarge but got: %v", err)
+	}
+}
+
+func TestParseMaxURLEncoded(t *testing.T) {
+	// MaxMemory only applies to multipart forms, so a small value should
+	// not limit the size of a urlencoded body.
+	body := "name=" + strings.Repeat("a", 4096)
+	req, err := http.NewRequest("POST", "/", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	d, err := ParseMax(req, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(d.Get("name")); got != 4096 {
+		t.Errorf("Expected name to have length 4096 but got %d", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	table := []struct {
+		contentType string
+		body        string
+		opts        Options
+		expected    []error
+	}{
+		{
+			// missing boundary
+			contentType: "multipart/form-data",
+			body:        "name=bob",
+			expected:    []error{ErrMultipart},
+		},
+		{
+			contentType: "application/x-www-form-urlencoded",
+			body:        "name=%zz",
+			expected:    []error{ErrURLEncoded},
+		},
+		{
+			contentType: "application/x-www-form-urlencoded",
+			body:        "name=bob",
+			opts:        Options{MaxBodySize: 4},
+			expected:    []error{ErrURLEncoded, ErrBodyTooLarge},
+		},
+		{
+			contentType: "application/json",
+			body:        "{",
+			expected:    []error{ErrJSON},
+		},
+	}
+	allKinds := []error{ErrMultipart, ErrURLEncoded, ErrJSON}
+	for i, test := range table {
+		req, err := http.NewRequest("POST", "/", strings.NewReader(test.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", test.contentType)
+		_, err = ParseWithOptions(req, test.opts)
+		if err == nil {
+			t.Errorf("Expected an error for test case %d but got none.", i)
+			continue
+		}
+		for _, expected := range test.expected {
+			if !errors.Is(err, expected) {
+				t.Errorf("Expected error in case %d to match %q but got: %s", i, expected, err)
+			}
+		}
+		for _, kind := range allKinds {
+			if kind != test.expected[0] && errors.Is(err, kind) {
+				t.Errorf("Expected error in case %d not to match %q but got: %s", i, kind, err)
+			}
+		}
+	}
+}
+
 func ExampleParse() {
 	// Construct a request object for example purposes only.
 	// Typically you would be using this inside a http.HandlerFunc,
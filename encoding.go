@@ -0,0 +1,79 @@
+// Copyright 2015 Alex Browne.  All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package forms
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// ErrContentEncoding is returned when a request body with a Content-Encoding
+// of gzip or deflate cannot be decompressed. Errors returned by Parse wrap the
+// underlying error, so they can be checked with errors.Is.
+var ErrContentEncoding = errors.New("forms: could not decompress body")
+
+// decompressBody returns a reader which decompresses body according to
+// encoding, the value of a Content-Encoding header. gzip and deflate are
+// supported, and body is returned as is for any other encoding. The
+// decompressed body is limited to max bytes (unless max is negative), after
+// which reads return ErrBodyTooLarge, to guard against small requests which
+// decompress to a huge size. The request itself is not modified. The caller
+// should close the result when done with it, which releases the decompressor
+// but does not close body.
+func decompressBody(body io.Reader, encoding string, max int64) (io.ReadCloser, error) {
+	var (
+		reader io.ReadCloser
+		err    error
+	)
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "gzip", "x-gzip":
+		reader, err = gzip.NewReader(body)
+	case "deflate":
+		// As per RFC 7230, deflate means the zlib format, not raw deflate.
+		reader, err = zlib.NewReader(body)
+	default:
+		return ioutil.NopCloser(body), nil
+	}
+	if err != nil {
+		return nil, &parseError{kind: ErrContentEncoding, err: err}
+	}
+	if max < 0 {
+		return reader, nil
+	}
+	return &decompressReader{reader: reader, remaining: max}, nil
+}
+
+// decompressReader reads from a decompressing reader and returns
+// ErrBodyTooLarge once more than remaining bytes have been read.
+type decompressReader struct {
+	reader    io.ReadCloser
+	remaining int64
+}
+
+func (dr *decompressReader) Read(p []byte) (int, error) {
+	if dr.remaining < 0 {
+		return 0, ErrBodyTooLarge
+	}
+	if int64(len(p)) > dr.remaining+1 {
+		p = p[:dr.remaining+1]
+	}
+	n, err := dr.reader.Read(p)
+	dr.remaining -= int64(n)
+	if dr.remaining < 0 {
+		return 0, ErrBodyTooLarge
+	}
+	if err != nil && err != io.EOF {
+		err = &parseError{kind: ErrContentEncoding, err: err}
+	}
+	return n, err
+}
+
+func (dr *decompressReader) Close() error {
+	return dr.reader.Close()
+}
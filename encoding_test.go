@@ -0,0 +1,122 @@
+// Copyright 2015 Alex Browne.  All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package forms
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func compressedRequest(t *testing.T, encoding string, body string) *http.Request {
+	req := compressedBodyRequest(t, encoding, body)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func compressedBodyRequest(t *testing.T, encoding string, body string) *http.Request {
+	buf := &bytes.Buffer{}
+	var w io.WriteCloser
+	if encoding == "deflate" {
+		w = zlib.NewWriter(buf)
+	} else {
+		w = gzip.NewWriter(buf)
+	}
+	if _, err := w.Write([]byte(body)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest("POST", "/", buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Encoding", encoding)
+	return req
+}
+
+func TestParseCompressed(t *testing.T) {
+	for _, encoding := range []string{"gzip", "deflate"} {
+		req := compressedRequest(t, encoding, "name=Bob&age=25&favoriteNumber=99.99&leftHanded=true")
+		contentLength := req.ContentLength
+		d, err := Parse(req)
+		if err != nil {
+			t.Fatalf("%s: %s", encoding, err)
+		}
+		testBasicFormFields(t, d)
+		// The request itself should not be modified
+		if got := req.Header.Get("Content-Encoding"); got != encoding {
+			t.Errorf("%s: Expected Content-Encoding to be unchanged but got %q", encoding, got)
+		}
+		if req.ContentLength != contentLength {
+			t.Errorf("%s: Expected ContentLength to be %d but got %d", encoding, contentLength, req.ContentLength)
+		}
+	}
+}
+
+func TestParseCompressedTooLarge(t *testing.T) {
+	// A small compressed body which decompresses to more than MaxDecompressedSize
+	req := compressedRequest(t, "gzip", "name="+strings.Repeat("a", 1024))
+	if _, err := ParseWithOptions(req, Options{MaxDecompressedSize: 512}); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("Expected ErrBodyTooLarge but got %v", err)
+	}
+}
+
+func TestParseCompressedMultipart(t *testing.T) {
+	// A file larger than MaxMemory should be stored on disk rather than
+	// causing an error.
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	if err := form.WriteField("name", "Bob"); err != nil {
+		t.Fatal(err)
+	}
+	file, err := form.CreateFormFile("file", "big.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents := strings.Repeat("a", 4096)
+	if _, err := file.Write([]byte(contents)); err != nil {
+		t.Fatal(err)
+	}
+	if err := form.Close(); err != nil {
+		t.Fatal(err)
+	}
+	req := compressedBodyRequest(t, "gzip", body.String())
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	d, err := ParseWithOptions(req, Options{MaxMemory: 1024})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer req.MultipartForm.RemoveAll()
+	if got := d.Get("name"); got != "Bob" {
+		t.Errorf("Expected name to be Bob but got %q", got)
+	}
+	got, err := d.GetFileBytes("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != contents {
+		t.Errorf("Expected file to have %d bytes but got %d", len(contents), len(got))
+	}
+}
+
+func TestParseCompressedInvalid(t *testing.T) {
+	req, err := http.NewRequest("POST", "/", strings.NewReader("name=Bob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Content-Encoding", "gzip")
+	if _, err := Parse(req); !errors.Is(err, ErrContentEncoding) {
+		t.Errorf("Expected ErrContentEncoding but got %v", err)
+	}
+}
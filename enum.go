@@ -0,0 +1,30 @@
+// Copyright 2015 Alex Browne.  All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package forms
+
+// GetEnum looks up the first element in data[key] in mapping and returns the
+// corresponding value. If the key does not exist or its value is not in
+// mapping, it returns def. It is useful for converting form values into typed
+// constants, e.g.
+//
+//	status := forms.GetEnum(data, "status", map[string]Status{
+//		"active":   StatusActive,
+//		"disabled": StatusDisabled,
+//	}, StatusActive)
+//
+// GetEnum is a function rather than a method because methods cannot have type
+// parameters. It requires Go 1.18 or later.
+func GetEnum[T comparable](d *Data, key string, mapping map[string]T, def T) T {
+	if !d.KeyExists(key) {
+		return def
+	}
+	if result, found := mapping[d.Get(key)]; found {
+		return result
+	}
+	return def
+}
@@ -0,0 +1,44 @@
+// Copyright 2015 Alex Browne.  All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package forms
+
+import (
+	"testing"
+)
+
+type testStatus int
+
+const (
+	statusUnknown testStatus = iota
+	statusActive
+	statusDisabled
+)
+
+func TestGetEnum(t *testing.T) {
+	data := newData()
+	data.Add("status", "active")
+	data.Add("other", "deleted")
+	mapping := map[string]testStatus{
+		"active":   statusActive,
+		"disabled": statusDisabled,
+	}
+
+	table := []struct {
+		key      string
+		expected testStatus
+	}{
+		{key: "status", expected: statusActive},
+		{key: "other", expected: statusUnknown},
+		{key: "missing", expected: statusUnknown},
+	}
+	for _, test := range table {
+		if got := GetEnum(data, test.key, mapping, statusUnknown); got != test.expected {
+			t.Errorf("%s was incorrect. Expected %d, but got %d.", test.key, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2015 Alex Browne.  All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package forms
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"mime"
+	"net/textproto"
+	"net/url"
+	"strings"
+)
+
+// parseURLEncoded reads a urlencoded body from r one key/value pair at a time
+// and calls fn for each pair, so that an error returned by fn (e.g. because
+// a limit was exceeded) stops parsing before the rest of the body is read. The
+// body is limited to max bytes. It returns all of the pairs which were read.
+// Errors returned by fn are returned as is, and any other error is wrapped as
+// ErrURLEncoded.
+func parseURLEncoded(r io.Reader, max int64, fn func(key string, val string) error) (url.Values, error) {
+	values := url.Values{}
+	reader := bufio.NewReader(io.LimitReader(r, max+1))
+	var read int64
+	for {
+		pair, readErr := reader.ReadString('&')
+		if readErr != nil && readErr != io.EOF {
+			return nil, &parseError{kind: ErrURLEncoded, err: readErr}
+		}
+		read += int64(len(pair))
+		if read > max {
+			return nil, &parseError{kind: ErrURLEncoded, err: ErrBodyTooLarge}
+		}
+		// Parse each pair with url.ParseQuery so that keys and values are
+		// unescaped exactly as they would be for the whole body.
+		parsed, err := url.ParseQuery(strings.TrimSuffix(pair, "&"))
+		if err != nil {
+			return nil, &parseError{kind: ErrURLEncoded, err: err}
+		}
+		for key, vals := range parsed {
+			for _, val := range vals {
+				values.Add(key, val)
+				if err := fn(key, val); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if readErr == io.EOF {
+			return values, nil
+		}
+	}
+}
+
+// maxPartHeaderSize is the largest header of a multipart part which
+// partCounter will inspect. Parts with larger headers are not counted, but
+// are still subject to the limits once the form has been read.
+const maxPartHeaderSize = 16 << 10
+
+// partCounter counts the non-file parts of a multipart body as it is read,
+// and fails with ErrTooManyKeys or ErrTooManyValues as soon as the limits in
+// opts are exceeded. This lets the limits be enforced while
+// multipart.Reader.ReadForm is reading the body, instead of after it has built
+// the whole form.
+type partCounter struct {
+	r         io.Reader
+	opts      Options
+	delimiter []byte
+	// buf holds bytes which have been read but not yet scanned.
+	buf []byte
+	// inHeader is true after a delimiter has been found but before the
+	// end of the part header.
+	inHeader bool
+	counts   map[string]int
+	err      error
+}
+
+// newPartCounter returns a partCounter which reads from r, a multipart body
+// with the given boundary.
+func newPartCounter(r io.Reader, boundary string, opts Options) *partCounter {
+	return &partCounter{
+		r:         r,
+		opts:      opts,
+		delimiter: []byte("\r\n--" + boundary),
+		// The first boundary is not preceded by a line break.
+		buf:    []byte("\r\n"),
+		counts: map[string]int{},
+	}
+}
+
+func (pc *partCounter) Read(p []byte) (int, error) {
+	if pc.err != nil {
+		return 0, pc.err
+	}
+	n, err := pc.r.Read(p)
+	pc.scan(p[:n])
+	if pc.err != nil {
+		return 0, pc.err
+	}
+	return n, err
+}
+
+// scan looks for part headers in b, along with any bytes left over from
+// previous calls, and counts each of the parts found.
+func (pc *partCounter) scan(b []byte) {
+	pc.buf = append(pc.buf, b...)
+	for pc.err == nil {
+		if !pc.inHeader {
+			i := bytes.Index(pc.buf, pc.delimiter)
+			if i < 0 {
+				// Keep enough bytes to find a delimiter which is split
+				// across reads.
+				if keep := len(pc.delimiter) - 1; len(pc.buf) > keep {
+					pc.buf = append(pc.buf[:0], pc.buf[len(pc.buf)-keep:]...)
+				}
+				return
+			}
+			pc.buf = pc.buf[i+len(pc.delimiter):]
+			pc.inHeader = true
+		}
+		end := bytes.Index(pc.buf, []byte("\r\n\r\n"))
+		if end < 0 {
+			if len(pc.buf) > maxPartHeaderSize {
+				pc.inHeader = false
+				pc.buf = pc.buf[:0]
+			}
+			return
+		}
+		pc.count(pc.buf[:end+4])
+		pc.buf = pc.buf[end+4:]
+		pc.inHeader = false
+	}
+}
+
+// count counts the part with the given header, which starts with the rest of
+// the delimiter line and ends with a blank line.
+func (pc *partCounter) count(header []byte) {
+	if i := bytes.Index(header, []byte("\r\n")); i >= 0 {
+		header = header[i+2:]
+	}
+	mimeHeader, err := textproto.NewReader(bufio.NewReader(bytes.NewReader(header))).ReadMIMEHeader()
+	if err != nil {
+		return
+	}
+	_, params, err := mime.ParseMediaType(mimeHeader.Get("Content-Disposition"))
+	if err != nil {
+		return
+	}
+	// As with multipart.Reader.ReadForm, parts without a name are ignored
+	// and parts with a filename are files rather than values.
+	name := params["name"]
+	if name == "" || params["filename"] != "" {
+		return
+	}
+	pc.counts[name]++
+	if pc.opts.MaxKeys >= 0 && len(pc.counts) > pc.opts.MaxKeys {
+		pc.err = ErrTooManyKeys
+	} else if pc.opts.MaxValuesPerKey >= 0 && pc.counts[name] > pc.opts.MaxValuesPerKey {
+		pc.err = ErrTooManyValues
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2015 Alex Browne.  All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package forms
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// endlessReader returns the result of next(0), next(1), and so on, forever.
+// It records the number of bytes read so far.
+type endlessReader struct {
+	next func(i int) string
+	i    int
+	buf  []byte
+	read int64
+}
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	for len(r.buf) < len(p) {
+		r.buf = append(r.buf, r.next(r.i)...)
+		r.i++
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	r.read += int64(n)
+	return n, nil
+}
+
+func TestParseLimitsStreaming(t *testing.T) {
+	const boundary = "xxboundaryxx"
+	multipartPart := func(key string) string {
+		return fmt.Sprintf("--%s\r\nContent-Disposition: form-data; name=%q\r\n\r\nvalue\r\n", boundary, key)
+	}
+	testCases := []struct {
+		name        string
+		contentType string
+		next        func(i int) string
+		err         error
+	}{
+		{
+			name:        "urlencoded keys",
+			contentType: "application/x-www-form-urlencoded",
+			next:        func(i int) string { return fmt.Sprintf("key%d=value&", i) },
+			err:         ErrTooManyKeys,
+		},
+		{
+			name:        "urlencoded values",
+			contentType: "application/x-www-form-urlencoded",
+			next:        func(i int) string { return "key=value&" },
+			err:         ErrTooManyValues,
+		},
+		{
+			name:        "multipart keys",
+			contentType: "multipart/form-data; boundary=" + boundary,
+			next:        func(i int) string { return multipartPart(fmt.Sprintf("key%d", i)) },
+			err:         ErrTooManyKeys,
+		},
+		{
+			name:        "multipart values",
+			contentType: "multipart/form-data; boundary=" + boundary,
+			next:        func(i int) string { return multipartPart("key") },
+			err:         ErrTooManyValues,
+		},
+		{
+			name:        "json keys",
+			contentType: "application/json",
+			next: func(i int) string {
+				if i == 0 {
+					return "{"
+				}
+				return fmt.Sprintf(`"key%d":"value",`, i)
+			},
+			err: ErrTooManyKeys,
+		},
+	}
+	for _, tc := range testCases {
+		body := &endlessReader{next: tc.next}
+		req, err := http.NewRequest("POST", "/", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", tc.contentType)
+		_, err = ParseWithOptions(req, Options{MaxKeys: 10, MaxValuesPerKey: 10})
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s: Expected %v but got %v", tc.name, tc.err, err)
+		}
+		// Limit errors are the same for every Content-Type, so they are not
+		// wrapped as errors parsing the body.
+		for _, kind := range []error{ErrJSON, ErrURLEncoded, ErrMultipart} {
+			if errors.Is(err, kind) {
+				t.Errorf("%s: Expected the limit error not to be %v but got %v", tc.name, kind, err)
+			}
+		}
+		// The body never ends, so it can only have been read in part.
+		if body.read > 64<<10 {
+			t.Errorf("%s: Expected the body to be read in part but %d bytes were read", tc.name, body.read)
+		}
+	}
+}
+
+func TestParseJSONStreaming(t *testing.T) {
+	newRequest := func(body string) *http.Request {
+		req, err := http.NewRequest("POST", "/", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	// As with json.Unmarshal, the last value for a duplicate key wins
+	d, err := Parse(newRequest(`{"name":"bob","name":"alice"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Values["name"]; len(got) != 1 || got[0] != "alice" {
+		t.Errorf(`Expected name to be ["alice"] but got %v`, got)
+	}
+
+	// A null body has no fields
+	d, err = Parse(newRequest("null"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Len() != 0 {
+		t.Errorf("Expected no keys for a null body but got %v", d.Keys())
+	}
+
+	// Bodies which are not a single object are invalid
+	for _, body := range []string{`["bob"]`, `"bob"`, `{"name":"bob"} {}`, `{"name":"bob"`, `{"name":"bob"}x`} {
+		if _, err := Parse(newRequest(body)); !errors.Is(err, ErrJSON) {
+			t.Errorf("%s: Expected ErrJSON but got %v", body, err)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2015 Alex Browne.  All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package forms
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MessageFunc returns the error message for a built-in validation rule which
+// failed for field. rule identifies the rule and is one of the Rule constants,
+// e.g. RuleRequired or RuleMinLength, and params holds any parameters of the
+// rule, such as the minimum length. The params for each rule are documented
+// alongside the constants. A MessageFunc can be installed with
+// Validator.WithMessages, e.g. to localize messages.
+type MessageFunc func(rule string, field string, params ...interface{}) string
+
+// The identifiers of the built-in validation rules, as passed to a
+// MessageFunc. The params passed along with each rule, if any, are noted in
+// the comments below. Rules without a note have no params.
+const (
+	RuleAccepted            = "accepted"
+	RuleNotBlank            = "not_blank"
+	RuleAllowedFields       = "allowed_fields"
+	RuleMaxBodySize         = "max_body_size" // params: n int64
+	RuleFileRead            = "file_read"
+	RuleRequired            = "required"
+	RuleFileEmpty           = "file_empty"
+	RuleMinLength           = "min_length"   // params: length int
+	RuleMaxLength           = "max_length"   // params: length int
+	RuleLengthRange         = "length_range" // params: min, max int
+	RuleEqual               = "equal"        // params: other field string
+	RuleDisallow            = "disallow"     // params: char rune
+	RuleDisallowPattern     = "disallow_pattern"
+	RuleEmail               = "email"
+	RuleURL                 = "url"
+	RuleIP                  = "ip"
+	RuleCIDR                = "cidr"
+	RuleMatch               = "match"
+	RuleSlug                = "slug"
+	RuleAlphanumeric        = "alphanumeric"
+	RuleHexadecimal         = "hexadecimal"
+	RuleNumeric             = "numeric"
+	RuleOneOf               = "one_of" // params: allowed []string
+	RuleTypeInt             = "type_int"
+	RuleTypeFloat           = "type_float"
+	RuleTypeBool            = "type_bool"
+	RuleGreater             = "greater"                // params: value float64
+	RuleGreaterOrEqual      = "greater_or_equal"       // params: value float64
+	RuleLess                = "less"                   // params: value float64
+	RuleLessOrEqual         = "less_or_equal"          // params: value float64
+	RuleGreaterField        = "greater_field"          // params: other field string
+	RuleGreaterOrEqualField = "greater_or_equal_field" // params: other field string
+	RuleIntRange            = "int_range"              // params: min, max int
+	RuleFloatRange          = "float_range"            // params: min, max float64
+	RuleDate                = "date"
+	RuleDateRange           = "date_range"     // params: min, max string, formatted using the layout
+	RuleCountBetween        = "count_between"  // params: min, max int
+	RuleCountExactly        = "count_exactly"  // params: n int
+	RuleCountAtLeast        = "count_at_least" // params: n int
+	RuleUnique              = "unique"         // params: duplicate value string
+	RulePasswordUpper       = "password_upper"
+	RulePasswordLower       = "password_lower"
+	RulePasswordDigit       = "password_digit"
+	RulePasswordSymbol      = "password_symbol"
+	RuleLuhn                = "luhn"
+	RulePhone               = "phone"
+	RuleFileExt             = "file_ext" // params: ext string, allowed []string
+)
+
+// defaultMessages holds a format string for each rule. The first argument is
+// always the field, followed by the params for the rule.
+var defaultMessages = map[string]string{
+	RuleAccepted:            "%[1]s must be accepted.",
+	RuleNotBlank:            "%[1]s cannot be blank.",
+	RuleAllowedFields:       "%[1]s is not an allowed field.",
+	RuleMaxBodySize:         "The request body must be no larger than %[2]d bytes.", // n int64
+	RuleFileRead:            "Could not read file.",
+	RuleRequired:            "%[1]s is required.",
+	RuleFileEmpty:           "%[1]s is required and cannot be an empty file.",
+	RuleMinLength:           "%[1]s must be at least %[2]d characters long.",          // length int
+	RuleMaxLength:           "%[1]s cannot be more than %[2]d characters long.",       // length int
+	RuleLengthRange:         "%[1]s must be between %[2]d and %[3]d characters long.", // min, max int
+	RuleEqual:               "%[2]s and %[1]s must match.",                            // other field string
+	RuleDisallow:            "%[1]s cannot contain %[2]q.",                            // char rune
+	RuleDisallowPattern:     "%[1]s contains characters which are not allowed.",
+	RuleEmail:               "%[1]s must be a valid email address.",
+	RuleURL:                 "%[1]s must be a valid URL.",
+	RuleIP:                  "%[1]s must be a valid IP address.",
+	RuleCIDR:                "%[1]s must be a valid CIDR block.",
+	RuleMatch:               "%[1]s must be correctly formatted.",
+	RuleSlug:                "%[1]s must be a lowercase slug (letters, digits, and dashes).",
+	RuleAlphanumeric:        "%[1]s must be made up of only letters and digits.",
+	RuleHexadecimal:         "%[1]s must be a hexadecimal value.",
+	RuleNumeric:             "%[1]s must be made up of only digits.",
+	RuleOneOf:               "%[1]s must be one of: %[2]s.", // allowed []string
+	RuleTypeInt:             "%[1]s must be an integer",
+	RuleTypeFloat:           "%[1]s must be a number",
+	RuleTypeBool:            "%[1]s must be a true or false",
+	RuleGreater:             "%[1]s must be greater than %[2]f.",             // value float64
+	RuleGreaterOrEqual:      "%[1]s must be greater than or equal to %[2]f.", // value float64
+	RuleLess:                "%[1]s must be less than %[2]f.",                // value float64
+	RuleLessOrEqual:         "%[1]s must be less than or equal to %[2]f.",    // value float64
+	RuleGreaterField:        "%[1]s must be greater than %[2]s.",             // other field string
+	RuleGreaterOrEqualField: "%[1]s must be greater than or equal to %[2]s.", // other field string
+	RuleIntRange:            "%[1]s must be between %[2]d and %[3]d.",        // min, max int
+	RuleFloatRange:          "%[1]s must be between %[2]g and %[3]g.",        // min, max float64
+	RuleDate:                "%[1]s must be a valid date.",
+	RuleDateRange:           "%[1]s must be between %[2]s and %[3]s.",          // min, max string (formatted with the layout)
+	RuleCountBetween:        "%[1]s must have between %[2]d and %[3]d values.", // min, max int
+	RuleCountExactly:        "%[1]s must have exactly %[2]d values.",           // n int
+	RuleCountAtLeast:        "%[1]s must have at least %[2]d values.",          // n int
+	RuleUnique:              "%[1]s cannot contain %[2]q more than once.",      // duplicate value string
+	RulePasswordUpper:       "%[1]s must contain an uppercase letter.",
+	RulePasswordLower:       "%[1]s must contain a lowercase letter.",
+	RulePasswordDigit:       "%[1]s must contain a digit.",
+	RulePasswordSymbol:      "%[1]s must contain a symbol.",
+	RuleLuhn:                "%[1]s must be a valid card number.",
+	RulePhone:               "%[1]s must be a valid phone number.",
+	RuleFileExt:             "The file extension %[2]s is not allowed. Allowed extensions include: %[3]s", // ext string, allowed []string
+}
+
+// DefaultMessage returns the English error message for a built-in validation
+// rule. It is used when no MessageFunc has been installed, and can be called
+// by a MessageFunc to fall back to English for rules it does not handle. Any
+// []string params are formatted as a list, e.g. "x, y, and z". If rule is not
+// a built-in rule, it returns a generic message.
+func DefaultMessage(rule string, field string, params ...interface{}) string {
+	format, found := defaultMessages[rule]
+	if !found {
+		return fmt.Sprintf("%s is invalid.", field)
+	}
+	if !strings.Contains(format, "%") {
+		return format
+	}
+	args := []interface{}{field}
+	for _, param := range params {
+		if list, ok := param.([]string); ok {
+			param = humanList(list)
+		}
+		args = append(args, param)
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// WithMessages installs fn as the source of error messages for the built-in
+// rules of v and returns v. It applies to any Validator returned by v.When as
+// well. Messages passed directly to AddError, or returned by the function given
+// to Custom, are not affected. Call WithMessages before adding any rules, since
+// messages are created when a rule fails.
+func (v *Validator) WithMessages(fn MessageFunc) *Validator {
+	v.messages = fn
+	return v
+}
+
+// addRuleError adds an error for field to v with the message for rule, as
+// given by the MessageFunc for v or DefaultMessage.
+func (v *Validator) addRuleError(rule string, field string, params ...interface{}) *ValidationResult {
+	return v.AddError(field, v.message(rule, field, params...))
+}
+
+// message returns the message for rule using the first MessageFunc found on
+// v or its parents, or DefaultMessage if there is none.
+func (v *Validator) message(rule string, field string, params ...interface{}) string {
+	for p := v; p != nil; p = p.parent {
+		if p.messages != nil {
+			return p.messages(rule, field, params...)
+		}
+	}
+	return DefaultMessage(rule, field, params...)
+}
@@ -0,0 +1,62 @@
+// Copyright 2015 Alex Browne.  All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package forms
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithMessages(t *testing.T) {
+	data := newData()
+	data.Add("name", "b")
+	data.Add("type", "business")
+	var rules []string
+	upper := func(rule string, field string, params ...interface{}) string {
+		rules = append(rules, rule)
+		return strings.ToUpper(DefaultMessage(rule, field, params...))
+	}
+	val := data.Validator().WithMessages(upper)
+	val.Require("email")
+	val.MinLength("name", 3)
+	val.When("type", "business").Require("company")
+	val.AddError("other", "Custom message.")
+
+	expected := map[string][]string{
+		"email":   []string{"EMAIL IS REQUIRED."},
+		"name":    []string{"NAME MUST BE AT LEAST 3 CHARACTERS LONG."},
+		"company": []string{"COMPANY IS REQUIRED."},
+		"other":   []string{"Custom message."},
+	}
+	if got := val.ErrorMap(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected errors %v but got %v", expected, got)
+	}
+	if expectedRules := []string{RuleRequired, RuleMinLength, RuleRequired}; !reflect.DeepEqual(rules, expectedRules) {
+		t.Errorf("Expected rules %v but got %v", expectedRules, rules)
+	}
+}
+
+func TestDefaultMessage(t *testing.T) {
+	table := []struct {
+		rule     string
+		field    string
+		params   []interface{}
+		expected string
+	}{
+		{rule: "required", field: "name", expected: "name is required."},
+		{rule: "length_range", field: "name", params: []interface{}{1, 10}, expected: "name must be between 1 and 10 characters long."},
+		{rule: "equal", field: "confirm", params: []interface{}{"password"}, expected: "password and confirm must match."},
+		{rule: "one_of", field: "color", params: []interface{}{[]string{"red", "green", "blue"}}, expected: "color must be one of: red, green, and blue."},
+		{rule: "max_body_size", field: "", params: []interface{}{int64(100)}, expected: "The request body must be no larger than 100 bytes."},
+		{rule: "file_read", field: "avatar", expected: "Could not read file."},
+		{rule: "unknown", field: "name", expected: "name is invalid."},
+	}
+	for _, test := range table {
+		if got := DefaultMessage(test.rule, test.field, test.params...); got != test.expected {
+			t.Errorf("%s: expected %q but got %q", test.rule, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2015 Alex Browne.  All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package forms
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Nested returns the values in d as a tree of nested maps, built from keys
+// which use bracket notation such as "user[name]" or "items[0][price]". It
+// is only available if d was parsed with Options.ExpandNested set, and
+// returns nil otherwise. The tree reflects the data at the time it was parsed
+// and is not updated by later calls to Add, Set, or Del.
+//
+// Each key is split into segments (e.g. "items[0][price]" becomes "items",
+// "0", and "price") and every segment except the last becomes a
+// map[string]interface{}. Numeric segments are treated as map keys, not slice
+// indexes. The last segment holds the first value for the key as a string,
+// unless it is empty (e.g. "tags[]"), in which case the parent segment holds
+// all of the values as a []string. Keys without brackets are stored at the top
+// level. If two keys conflict (e.g. "user=bob" and "user[name]=bob"), the one
+// which is expanded first wins and the other is left out of the tree. Flat
+// access through Get and friends is unaffected.
+func (d Data) Nested() map[string]interface{} {
+	return d.nested
+}
+
+// GetNested returns the value in the tree returned by Nested at the given
+// path, e.g. GetNested("user", "name") for the key "user[name]". The result is
+// a string, a []string, or a map[string]interface{} (see Nested). It returns
+// nil if there is no value at path or d was not parsed with
+// Options.ExpandNested.
+func (d Data) GetNested(path ...string) interface{} {
+	if d.nested == nil || len(path) == 0 {
+		return nil
+	}
+	var current interface{} = d.nested
+	for _, segment := range path {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		if current, ok = m[segment]; !ok {
+			return nil
+		}
+	}
+	return current
+}
+
+// expandNested builds the tree described by Nested from values. Keys are
+// expanded in sorted order so that conflicts are resolved consistently.
+func expandNested(values url.Values) map[string]interface{} {
+	root := map[string]interface{}{}
+	for _, key := range (Data{Values: values}).Keys() {
+		segments, ok := splitNestedKey(key)
+		if !ok {
+			segments = []string{key}
+		}
+		vals := values[key]
+		if len(vals) == 0 {
+			continue
+		}
+		parent := root
+		last := len(segments) - 1
+		if segments[last] == "" && last > 0 {
+			// "tags[]" holds every value in the parent segment.
+			last--
+		}
+		for _, segment := range segments[:last] {
+			child, exists := parent[segment]
+			if !exists {
+				child = map[string]interface{}{}
+				parent[segment] = child
+			}
+			m, isMap := child.(map[string]interface{})
+			if !isMap {
+				parent = nil
+				break
+			}
+			parent = m
+		}
+		if parent == nil {
+			continue
+		}
+		if _, exists := parent[segments[last]]; exists {
+			continue
+		}
+		if last < len(segments)-1 {
+			parent[segments[last]] = append([]string(nil), vals...)
+		} else {
+			parent[segments[last]] = vals[0]
+		}
+	}
+	return root
+}
+
+// splitNestedKey splits a key like "items[0][price]" into its segments. It
+// returns false if key does not use well-formed bracket notation.
+func splitNestedKey(key string) ([]string, bool) {
+	open := strings.IndexByte(key, '[')
+	if open <= 0 || !strings.HasSuffix(key, "]") {
+		return nil, false
+	}
+	segments := []string{key[:open]}
+	rest := key[open:]
+	for rest != "" {
+		if rest[0] != '[' {
+			return nil, false
+		}
+		end := strings.IndexByte(rest, ']')
+		if end < 0 {
+			return nil, false
+		}
+		segment := rest[1:end]
+		if strings.IndexByte(segment, '[') >= 0 {
+			return nil, false
+		}
+		segments = append(segments, segment)
+		rest = rest[end+1:]
+	}
+	return segments, true
+}
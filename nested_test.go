@@ -0,0 +1,90 @@
+// Copyright 2015 Alex Browne.  All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package forms
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNested(t *testing.T) {
+	body := "user[name]=bob&user[age]=3&items[0][price]=9.99&tags[]=a&tags[]=b&plain=x"
+	req, err := http.NewRequest("POST", "/", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	d, err := ParseWithOptions(req, Options{ExpandNested: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := d.GetNested("user", "name"); got != "bob" {
+		t.Errorf("Expected user.name to be bob but got %v", got)
+	}
+	if got := d.GetNested("user", "age"); got != "3" {
+		t.Errorf("Expected user.age to be 3 but got %v", got)
+	}
+	if got := d.GetNested("items", "0", "price"); got != "9.99" {
+		t.Errorf("Expected items.0.price to be 9.99 but got %v", got)
+	}
+	if got := d.GetNested("tags"); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Expected tags to be [a b] but got %v", got)
+	}
+	if got := d.GetNested("plain"); got != "x" {
+		t.Errorf("Expected plain to be x but got %v", got)
+	}
+	if got := d.GetNested("user", "missing"); got != nil {
+		t.Errorf("Expected nil for missing path but got %v", got)
+	}
+	if got := d.GetNested("plain", "deeper"); got != nil {
+		t.Errorf("Expected nil for path through a string but got %v", got)
+	}
+	expectedUser := map[string]interface{}{"name": "bob", "age": "3"}
+	if got := d.Nested()["user"]; !reflect.DeepEqual(got, expectedUser) {
+		t.Errorf("Expected user to be %v but got %v", expectedUser, got)
+	}
+
+	// Flat access should still work
+	if got := d.Get("user[name]"); got != "bob" {
+		t.Errorf("Expected flat user[name] to be bob but got %s", got)
+	}
+}
+
+func TestNestedDisabled(t *testing.T) {
+	req, err := http.NewRequest("GET", "/?user[name]=bob", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := ParseWithOptions(req, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Nested() != nil {
+		t.Errorf("Expected Nested to be nil without ExpandNested but got %v", d.Nested())
+	}
+	if got := d.GetNested("user", "name"); got != nil {
+		t.Errorf("Expected GetNested to return nil without ExpandNested but got %v", got)
+	}
+}
+
+func TestNestedConflict(t *testing.T) {
+	req, err := http.NewRequest("GET", "/?user=alice&user[name]=bob&bad[key=x", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := ParseWithOptions(req, Options{ExpandNested: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.GetNested("user"); got != "alice" {
+		t.Errorf("Expected user to be alice but got %v", got)
+	}
+	if got := d.GetNested("bad[key"); got != "x" {
+		t.Errorf("Expected malformed key to be stored as is but got %v", got)
+	}
+}
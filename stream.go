@@ -0,0 +1,75 @@
+// Copyright 2015 Alex Browne.  All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package forms
+
+import (
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+)
+
+// ParseMultipartStream parses a multipart request without buffering any files
+// in memory or on disk. The body is read one part at a time. For each file
+// part, fn is called with the part so that the caller can stream its contents
+// elsewhere (e.g. with io.Copy). Each part must be consumed by fn before it
+// returns; the part cannot be read afterwards. If fn is nil, file parts are
+// discarded. Any other parts are treated as form fields and added to the
+// returned Data, as are url query parameters. The Files field of the returned
+// Data is always empty.
+//
+// If fn returns an error, parsing stops and that error is returned. Form fields
+// are limited to DefaultMaxFormSize bytes in total, after which ErrBodyTooLarge
+// is returned. Use ParseMultipartStreamWithOptions to change this limit.
+func ParseMultipartStream(req *http.Request, fn func(part *multipart.Part) error) (*Data, error) {
+	return ParseMultipartStreamWithOptions(req, Options{}, fn)
+}
+
+// ParseMultipartStreamWithOptions is like ParseMultipartStream, but uses opts
+// to configure how form fields are parsed. Form fields are limited to
+// opts.MaxMemory bytes in total, since they are held in memory. Options which
+// only apply to other kinds of requests, such as BodyKey, or which would
+// require buffering the body, such as KeepRawBody, are ignored.
+func ParseMultipartStreamWithOptions(req *http.Request, opts Options, fn func(part *multipart.Part) error) (*Data, error) {
+	reader, err := req.MultipartReader()
+	if err != nil {
+		return nil, &parseError{kind: ErrMultipart, err: err}
+	}
+	opts = opts.withDefaults()
+	data := newData()
+	data.contentLength = req.ContentLength
+	remaining := opts.MaxMemory
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, &parseError{kind: ErrMultipart, err: err}
+		}
+		if part.FileName() != "" {
+			if fn == nil {
+				if _, err := io.Copy(ioutil.Discard, part); err != nil {
+					return nil, &parseError{kind: ErrMultipart, err: err}
+				}
+			} else if err := fn(part); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		val, err := readAllMax(part, remaining)
+		if err != nil {
+			return nil, &parseError{kind: ErrMultipart, err: err}
+		}
+		remaining -= int64(len(val))
+		if err := opts.add(data, part.FormName(), string(val)); err != nil {
+			return nil, err
+		}
+	}
+	if err := parseQuery(req, data, opts); err != nil {
+		return nil, err
+	}
+	opts.finish(data)
+	return data, nil
+}
@@ -0,0 +1,175 @@
+// Copyright 2015 Alex Browne.  All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package forms
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestParseMultipartStream(t *testing.T) {
+	// Construct a multipart request with a large file part
+	body := bytes.NewBuffer([]byte{})
+	form := multipart.NewWriter(body)
+	if err := form.WriteField("name", "Bob"); err != nil {
+		t.Fatal(err)
+	}
+	fileWriter, err := form.CreateFormFile("upload", "large_file.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	largeFile := bytes.Repeat([]byte("0123456789"), 100000)
+	if _, err := fileWriter.Write(largeFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := form.WriteField("age", "25"); err != nil {
+		t.Fatal(err)
+	}
+	if err := form.Close(); err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest("POST", "/?page=1", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Content-Type", "multipart/form-data; boundary="+form.Boundary())
+
+	seen := map[string]int64{}
+	d, err := ParseMultipartStream(req, func(part *multipart.Part) error {
+		n, err := io.Copy(ioutil.Discard, part)
+		seen[part.FormName()+"/"+part.FileName()] = n
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := seen["upload/large_file.bin"]; got != int64(len(largeFile)) {
+		t.Errorf("Expected callback to read %d bytes of upload but got %d. Saw: %v", len(largeFile), got, seen)
+	}
+	if len(seen) != 1 {
+		t.Errorf("Expected callback to be called once but got: %v", seen)
+	}
+	for key, expected := range map[string]string{"name": "Bob", "age": "25", "page": "1"} {
+		if got := d.Get(key); got != expected {
+			t.Errorf(`Expected %s to be "%s" but got "%s"`, key, expected, got)
+		}
+	}
+	if len(d.Files) != 0 {
+		t.Errorf("Expected Files to be empty but got %v", d.Files)
+	}
+}
+
+func TestParseMultipartStreamErrors(t *testing.T) {
+	body := bytes.NewBuffer([]byte{})
+	form := multipart.NewWriter(body)
+	fileWriter, err := form.CreateFormFile("upload", "file.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fileWriter.Write([]byte("Hello!")); err != nil {
+		t.Fatal(err)
+	}
+	if err := form.Close(); err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest("POST", "/", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Content-Type", "multipart/form-data; boundary="+form.Boundary())
+
+	callbackErr := errors.New("disk full")
+	if _, err := ParseMultipartStream(req, func(part *multipart.Part) error {
+		return callbackErr
+	}); err != callbackErr {
+		t.Errorf("Expected the callback error to be returned but got: %v", err)
+	}
+
+	req, err = http.NewRequest("POST", "/", bytes.NewBufferString("name=bob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	if _, err := ParseMultipartStream(req, nil); !errors.Is(err, ErrMultipart) {
+		t.Errorf("Expected an ErrMultipart for a non-multipart request but got: %v", err)
+	}
+}
+
+func TestParseMultipartStreamNilCallback(t *testing.T) {
+	body := bytes.NewBuffer([]byte{})
+	form := multipart.NewWriter(body)
+	fileWriter, err := form.CreateFormFile("upload", "file.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fileWriter.Write([]byte("Hello!")); err != nil {
+		t.Fatal(err)
+	}
+	if err := form.WriteField("name", "Bob"); err != nil {
+		t.Fatal(err)
+	}
+	if err := form.Close(); err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest("POST", "/", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Content-Type", "multipart/form-data; boundary="+form.Boundary())
+
+	d, err := ParseMultipartStream(req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Get("name"); got != "Bob" {
+		t.Errorf(`Expected name to be "Bob" but got "%s"`, got)
+	}
+}
+
+func TestParseMultipartStreamWithOptions(t *testing.T) {
+	newRequest := func() *http.Request {
+		body := bytes.NewBuffer([]byte{})
+		form := multipart.NewWriter(body)
+		if err := form.WriteField("name", "  Bob  "); err != nil {
+			t.Fatal(err)
+		}
+		if err := form.WriteField("bio", strings.Repeat("a", 64)); err != nil {
+			t.Fatal(err)
+		}
+		if err := form.Close(); err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest("POST", "/", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Content-Type", "multipart/form-data; boundary="+form.Boundary())
+		return req
+	}
+
+	d, err := ParseMultipartStreamWithOptions(newRequest(), Options{TrimSpace: true, Defaults: map[string]string{"age": "0"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Get("name"); got != "Bob" {
+		t.Errorf(`Expected name to be trimmed to "Bob" but got "%s"`, got)
+	}
+	if got := d.Get("age"); got != "0" {
+		t.Errorf(`Expected age to default to "0" but got "%s"`, got)
+	}
+
+	if _, err := ParseMultipartStreamWithOptions(newRequest(), Options{MaxMemory: 32}, nil); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("Expected ErrBodyTooLarge but got %v", err)
+	}
+	if _, err := ParseMultipartStreamWithOptions(newRequest(), Options{MaxKeys: 1}, nil); err != ErrTooManyKeys {
+		t.Errorf("Expected ErrTooManyKeys but got %v", err)
+	}
+}
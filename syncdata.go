@@ -0,0 +1,80 @@
+// Copyright 2015 Alex Browne.  All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package forms
+
+import (
+	"sync"
+)
+
+// SyncData wraps a Data and guards it with a sync.RWMutex, so that it can be
+// safely shared between goroutines (e.g. by concurrent middleware). Data
+// itself is not safe for concurrent use, which is fine for the common case of
+// a single goroutine handling a request. Once a Data is wrapped, it should only
+// be accessed through the SyncData.
+type SyncData struct {
+	mut  sync.RWMutex
+	data *Data
+}
+
+// NewSyncData returns a SyncData which wraps d.
+func NewSyncData(d *Data) *SyncData {
+	return &SyncData{data: d}
+}
+
+// Add is like Data.Add but safe for concurrent use.
+func (sd *SyncData) Add(key string, value string) {
+	sd.mut.Lock()
+	defer sd.mut.Unlock()
+	sd.data.Add(key, value)
+}
+
+// Set is like Data.Set but safe for concurrent use.
+func (sd *SyncData) Set(key string, value string) {
+	sd.mut.Lock()
+	defer sd.mut.Unlock()
+	sd.data.Set(key, value)
+}
+
+// Del is like Data.Del but safe for concurrent use.
+func (sd *SyncData) Del(key string) {
+	sd.mut.Lock()
+	defer sd.mut.Unlock()
+	sd.data.Del(key)
+}
+
+// Get is like Data.Get but safe for concurrent use.
+func (sd *SyncData) Get(key string) string {
+	sd.mut.RLock()
+	defer sd.mut.RUnlock()
+	return sd.data.Get(key)
+}
+
+// GetStrings is like Data.GetStrings but safe for concurrent use. It
+// returns a copy of the values, since the underlying slice may be
+// modified by other goroutines.
+func (sd *SyncData) GetStrings(key string) []string {
+	sd.mut.RLock()
+	defer sd.mut.RUnlock()
+	vals := sd.data.GetStrings(key)
+	if vals == nil {
+		return nil
+	}
+	return append([]string(nil), vals...)
+}
+
+// KeyExists is like Data.KeyExists but safe for concurrent use.
+func (sd *SyncData) KeyExists(key string) bool {
+	sd.mut.RLock()
+	defer sd.mut.RUnlock()
+	return sd.data.KeyExists(key)
+}
+
+// Data returns a copy of the underlying Data (see Data.Clone), which
+// can be used freely without holding any locks.
+func (sd *SyncData) Data() *Data {
+	sd.mut.RLock()
+	defer sd.mut.RUnlock()
+	return sd.data.Clone()
+}
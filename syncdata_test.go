@@ -0,0 +1,50 @@
+// Copyright 2015 Alex Browne.  All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package forms
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestSyncData(t *testing.T) {
+	data := newData()
+	data.Add("name", "bob")
+	sd := NewSyncData(data)
+
+	// Run with -race to detect unsynchronized access
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			sd.Add("ids", strconv.Itoa(i))
+			sd.Set("last", strconv.Itoa(i))
+		}(i)
+		go func() {
+			defer wg.Done()
+			sd.Get("name")
+			sd.GetStrings("ids")
+			sd.KeyExists("last")
+		}()
+	}
+	wg.Wait()
+
+	if got := len(sd.GetStrings("ids")); got != 10 {
+		t.Errorf("Expected 10 ids but got %d.", got)
+	}
+	sd.Del("name")
+	if sd.KeyExists("name") {
+		t.Error("Expected name to be deleted but it was not.")
+	}
+
+	// The copy returned by Data should be independent
+	clone := sd.Data()
+	clone.Set("name", "bill")
+	if sd.KeyExists("name") {
+		t.Error("Expected modifying the result of Data to leave the SyncData unchanged.")
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2015 Alex Browne.  All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package forms
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Kind identifies the type that a value should be converted to by GetTyped.
+type Kind int
+
+const (
+	// KindString leaves the value as a string.
+	KindString Kind = iota
+	// KindInt converts the value to an int.
+	KindInt
+	// KindFloat converts the value to a float64.
+	KindFloat
+	// KindBool converts the value to a bool.
+	KindBool
+)
+
+// ConversionErrors is returned by GetTyped when one or more values could not
+// be converted. The keys are the keys from the spec, and the values are the
+// errors which occurred while converting the corresponding value.
+type ConversionErrors map[string]error
+
+// Error returns all the errors, sorted by key and separated by "; ".
+func (errs ConversionErrors) Error() string {
+	keys := make([]string, 0, len(errs))
+	for key := range errs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	msgs := make([]string, len(keys))
+	for i, key := range keys {
+		msgs[i] = fmt.Sprintf("%s: %s", key, errs[key])
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// GetTyped converts the first element in data[key] for every key in spec to
+// the corresponding Kind, and returns the results in a map. The value in the
+// map will be a string, int, float64, or bool, depending on the Kind. Keys
+// which do not exist in d are omitted from the result. If any value cannot be
+// converted, GetTyped still converts every other value and returns them along
+// with a ConversionErrors describing every failure.
+func (d Data) GetTyped(spec map[string]Kind) (map[string]interface{}, error) {
+	results := map[string]interface{}{}
+	errs := ConversionErrors{}
+	for key, kind := range spec {
+		if !d.KeyExists(key) || len(d.Values[key]) == 0 {
+			continue
+		}
+		var result interface{}
+		var err error
+		switch kind {
+		case KindString:
+			result = d.Get(key)
+		case KindInt:
+			result, err = d.GetIntErr(key)
+		case KindFloat:
+			result, err = d.GetFloatErr(key)
+		case KindBool:
+			result, err = d.GetBoolErr(key)
+		default:
+			err = fmt.Errorf("unknown kind %d", kind)
+		}
+		if err != nil {
+			errs[key] = err
+			continue
+		}
+		results[key] = result
+	}
+	if len(errs) > 0 {
+		return results, errs
+	}
+	return results, nil
+}
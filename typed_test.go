@@ -0,0 +1,71 @@
+// Copyright 2015 Alex Browne.  All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package forms
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGetTyped(t *testing.T) {
+	data := newData()
+	data.Add("name", "bob")
+	data.Add("age", "25")
+	data.Add("rating", "4.5")
+	data.Add("admin", "true")
+
+	spec := map[string]Kind{
+		"name":    KindString,
+		"age":     KindInt,
+		"rating":  KindFloat,
+		"admin":   KindBool,
+		"missing": KindInt,
+	}
+	got, err := data.GetTyped(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"name":   "bob",
+		"age":    25,
+		"rating": 4.5,
+		"admin":  true,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Result of GetTyped was incorrect. Expected %v, but got %v.\n", expected, got)
+	}
+}
+
+func TestGetTypedErrors(t *testing.T) {
+	data := newData()
+	data.Add("name", "bob")
+	data.Add("age", "twenty-five")
+	data.Add("admin", "sometimes")
+
+	spec := map[string]Kind{
+		"name":  KindString,
+		"age":   KindInt,
+		"admin": KindBool,
+	}
+	got, err := data.GetTyped(spec)
+	if err == nil {
+		t.Fatal("Expected an error but got none.")
+	}
+	errs, ok := err.(ConversionErrors)
+	if !ok {
+		t.Fatalf("Expected a ConversionErrors but got %T.", err)
+	}
+	if len(errs) != 2 || errs["age"] == nil || errs["admin"] == nil {
+		t.Errorf("Expected errors for age and admin but got: %v", errs)
+	}
+	if msg := err.Error(); !strings.HasPrefix(msg, "admin: ") || !strings.Contains(msg, "; age: ") {
+		t.Errorf("Expected error message to list errors in sorted order but got: %s", msg)
+	}
+	// Values which could be converted should still be returned
+	if !reflect.DeepEqual(got, map[string]interface{}{"name": "bob"}) {
+		t.Errorf(`Expected result to contain only name but got %v`, got)
+	}
+}
@@ -5,11 +5,20 @@
 package forms
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Validator has methods for validating its underlying Data.
@@ -21,6 +30,12 @@ import (
 type Validator struct {
 	data    *Data
 	results []*ValidationResult
+	// parent and skip are only set for validators returned by When.
+	parent *Validator
+	skip   bool
+	// messages, if non-nil, is used to create the messages for
+	// built-in rules (see WithMessages).
+	messages MessageFunc
 }
 
 // ValidationResult is returned from every validation method and can
@@ -53,6 +68,14 @@ func (vr *ValidationResult) Message(msg string) *ValidationResult {
 // should typically be a user-readable sentence, such as "username
 // is required."
 func (v *Validator) AddError(field string, msg string) *ValidationResult {
+	if v.skip {
+		// The condition for a validator returned by When was not met,
+		// so the error is discarded.
+		return &ValidationResult{field: field, message: msg}
+	}
+	if v.parent != nil {
+		return v.parent.AddError(field, msg)
+	}
 	result := &ValidationResult{
 		field:   field,
 		message: msg,
@@ -61,6 +84,33 @@ func (v *Validator) AddError(field string, msg string) *ValidationResult {
 	return result
 }
 
+// When returns a Validator whose rules only apply if the first element
+// of data.Values[field] is equal to value. If the condition is met, any
+// errors are added to v, so they should be checked with v.HasErrors,
+// v.ErrorMap, etc. If the condition is not met, any errors are
+// discarded. For example, to require company_name only for business
+// accounts:
+//
+//	val.When("account_type", "business").Require("company_name")
+func (v *Validator) When(field string, value string) *Validator {
+	return &Validator{
+		data:   v.data,
+		parent: v,
+		skip:   v.data.Get(field) != value,
+	}
+}
+
+// skipped returns true if v or any of its parents was returned by When and
+// the condition was not met.
+func (v *Validator) skipped() bool {
+	for ; v != nil; v = v.parent {
+		if v.skip {
+			return true
+		}
+	}
+	return false
+}
+
 // HasErrors returns true iff the Validator has errors, i.e.
 // if any validation methods called on the Validator failed.
 func (v *Validator) HasErrors() bool {
@@ -103,6 +153,61 @@ func (v *Validator) ErrorMap() map[string][]string {
 	return errMap
 }
 
+// FieldError holds a field name and an error message associated with it.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// OrderedErrors returns the fields and messages for all validation
+// results for the Validator, in the order they were added. Unlike
+// ErrorMap, the order is deterministic, which is useful for displaying
+// errors in the same order as the fields in a form.
+func (v *Validator) OrderedErrors() []FieldError {
+	errs := []FieldError{}
+	for _, vr := range v.results {
+		errs = append(errs, FieldError{Field: vr.field, Message: vr.message})
+	}
+	return errs
+}
+
+// ValidationError is returned by Err when a Validator has errors. It
+// implements the error interface and can be marshaled to json in the
+// form {"errors": {"field": ["message", ...]}}, making it convenient to
+// write directly to an http response.
+type ValidationError struct {
+	// Errors maps field names to any error messages associated with
+	// that field name, in the same format as ErrorMap.
+	Errors   map[string][]string
+	messages []string
+}
+
+// Error returns all the error messages for the validator, in order,
+// separated by a space.
+func (e *ValidationError) Error() string {
+	return strings.Join(e.messages, " ")
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *ValidationError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]map[string][]string{
+		"errors": e.Errors,
+	})
+}
+
+// Err returns nil if the Validator has no errors. Otherwise it returns
+// a *ValidationError holding all the fields and error messages for the
+// Validator.
+func (v *Validator) Err() error {
+	if !v.HasErrors() {
+		return nil
+	}
+	return &ValidationError{
+		Errors:   v.ErrorMap(),
+		messages: v.Messages(),
+	}
+}
+
 // Require will add an error to the Validator if data.Values[field]
 // does not exist, is an empty string, or consists of only
 // whitespace.
@@ -114,6 +219,110 @@ func (v *Validator) Require(field string) *ValidationResult {
 	}
 }
 
+// Accepted will add an error to the Validator unless the first element of
+// data.Values[field] is a truthy checkbox value as recognized by
+// Data.GetBoolLoose, such as "on", "yes", "true", or "1". It is useful for
+// checkboxes which must be checked, e.g. to accept terms of service. Since
+// browsers do not submit unchecked checkboxes, an error is also added if
+// data.Values[field] does not exist.
+func (v *Validator) Accepted(field string) *ValidationResult {
+	if !v.data.GetBoolLoose(field) {
+		return v.addRuleError(RuleAccepted, field)
+	}
+	return validationOk
+}
+
+// RequiredWith will add an error to the Validator if data.Values[field] is
+// missing or blank (as with Require) and any of others has a value (see
+// Data.HasValue). For example, to require a city whenever a street address is
+// given:
+//
+//	val.RequiredWith("city", "street")
+func (v *Validator) RequiredWith(field string, others ...string) *ValidationResult {
+	for _, other := range others {
+		if v.data.HasValue(other) {
+			return v.Require(field)
+		}
+	}
+	return validationOk
+}
+
+// RequiredWithout will add an error to the Validator if data.Values[field] is
+// missing or blank (as with Require) and any of others does not have a value
+// (see Data.HasValue). For example, to require an email address when no phone
+// number is given:
+//
+//	val.RequiredWithout("email", "phone")
+func (v *Validator) RequiredWithout(field string, others ...string) *ValidationResult {
+	for _, other := range others {
+		if !v.data.HasValue(other) {
+			return v.Require(field)
+		}
+	}
+	return validationOk
+}
+
+// NotBlank will add an error to the Validator if data.Values[field] exists
+// but is empty or consists of only whitespace. Unlike Require, it does not
+// add an error if data.Values[field] does not exist, so the two can be used
+// together to give different messages for missing and blank fields.
+func (v *Validator) NotBlank(field string) *ValidationResult {
+	if !v.data.KeyExists(field) {
+		return validationOk
+	}
+	if strings.TrimSpace(v.data.Get(field)) == "" {
+		return v.addRuleError(RuleNotBlank, field)
+	}
+	return validationOk
+}
+
+// RequireFields calls Require for each field in fields, adding an error
+// to the Validator for every field which is missing or blank. Use Require
+// directly if you need to change the field name or message.
+func (v *Validator) RequireFields(fields ...string) {
+	for _, field := range fields {
+		v.Require(field)
+	}
+}
+
+// AllowFields will add an error to the Validator for every key in
+// data.Values or data.Files which is not in fields. It is the inverse of
+// RequireFields and can be used to catch typos or unexpected input. Errors
+// are added in sorted order by key. Unlike Data.Only, it does not modify
+// the data.
+func (v *Validator) AllowFields(fields ...string) {
+	allowed := map[string]bool{}
+	for _, field := range fields {
+		allowed[field] = true
+	}
+	keys := v.data.Keys()
+	for key := range v.data.Files {
+		if !v.data.KeyExists(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !allowed[key] {
+			v.addRuleError(RuleAllowedFields, key)
+		}
+	}
+}
+
+// MaxBodySize will add an error to the Validator if the body of the request
+// the data was parsed from was larger than n bytes, according to its
+// Content-Length (see Data.ContentLength). Unlike Options.MaxBodySize, it does
+// not stop the request from being parsed, so it can be used to enforce a
+// different limit for each form. The error applies to the form as a whole, so
+// its field is the empty string; use Field to change it. If the length is
+// unknown, it does not add an error to the Validator.
+func (v *Validator) MaxBodySize(n int64) *ValidationResult {
+	if length := v.data.ContentLength(); length > n {
+		return v.addRuleError(RuleMaxBodySize, "", n)
+	}
+	return validationOk
+}
+
 // RequireFile will add an error to the Validator if data.Files[field]
 // does not exist or is an empty file
 func (v *Validator) RequireFile(field string) *ValidationResult {
@@ -122,7 +331,7 @@ func (v *Validator) RequireFile(field string) *ValidationResult {
 	}
 	bytes, err := v.data.GetFileBytes(field)
 	if err != nil {
-		return v.AddError(field, "Could not read file.")
+		return v.addRuleError(RuleFileRead, field)
 	}
 	if len(bytes) == 0 {
 		return v.addFileEmptyError(field)
@@ -131,23 +340,26 @@ func (v *Validator) RequireFile(field string) *ValidationResult {
 }
 
 func (v *Validator) addRequiredError(field string) *ValidationResult {
-	msg := fmt.Sprintf("%s is required.", field)
-	return v.AddError(field, msg)
+	return v.addRuleError(RuleRequired, field)
 }
 
 func (v *Validator) addFileEmptyError(field string) *ValidationResult {
-	msg := fmt.Sprintf("%s is required and cannot be an empty file.", field)
-	return v.AddError(field, msg)
+	return v.addRuleError(RuleFileEmpty, field)
 }
 
 // MinLength will add an error to the Validator if data.Values[field]
 // is shorter than length (if data.Values[field] has less than
 // length characters), not counting leading or trailing
-// whitespace.
+// whitespace. Characters are counted as runes, not bytes. If
+// data.Values[field] does not exist, it does not add an error to the
+// Validator. Use Require to check for existence.
 func (v *Validator) MinLength(field string, length int) *ValidationResult {
+	if !v.data.KeyExists(field) {
+		return validationOk
+	}
 	val := v.data.Get(field)
 	trimmed := strings.TrimSpace(val)
-	if len(trimmed) < length {
+	if utf8.RuneCountInString(trimmed) < length {
 		return v.addMinLengthError(field, length)
 	} else {
 		return validationOk
@@ -155,18 +367,22 @@ func (v *Validator) MinLength(field string, length int) *ValidationResult {
 }
 
 func (v *Validator) addMinLengthError(field string, length int) *ValidationResult {
-	msg := fmt.Sprintf("%s must be at least %d characters long.", field, length)
-	return v.AddError(field, msg)
+	return v.addRuleError(RuleMinLength, field, length)
 }
 
 // MaxLength will add an error to the Validator if data.Values[field]
 // is longer than length (if data.Values[field] has more than
 // length characters), not counting leading or trailing
-// whitespace.
+// whitespace. Characters are counted as runes, not bytes. If
+// data.Values[field] does not exist, it does not add an error to the
+// Validator.
 func (v *Validator) MaxLength(field string, length int) *ValidationResult {
+	if !v.data.KeyExists(field) {
+		return validationOk
+	}
 	val := v.data.Get(field)
 	trimmed := strings.TrimSpace(val)
-	if len(trimmed) > length {
+	if utf8.RuneCountInString(trimmed) > length {
 		return v.addMaxLengthError(field, length)
 	} else {
 		return validationOk
@@ -174,17 +390,19 @@ func (v *Validator) MaxLength(field string, length int) *ValidationResult {
 }
 
 func (v *Validator) addMaxLengthError(field string, length int) *ValidationResult {
-	msg := fmt.Sprintf("%s cannot be more than %d characters long.", field, length)
-	return v.AddError(field, msg)
+	return v.addRuleError(RuleMaxLength, field, length)
 }
 
 // LengthRange will add an error to the Validator if data.Values[field]
 // is shorter than min (if data.Values[field] has less than
 // min characters) or if data.Values[field] is longer than max
 // (if data.Values[field] has more than max characters), not
-// counting leading or trailing whitespace.
+// counting leading or trailing whitespace. As with MinLength and
+// MaxLength, characters are counted as runes. Whitespace is trimmed
+// here regardless of whether Options.TrimSpace was used when parsing.
 func (v *Validator) LengthRange(field string, min int, max int) *ValidationResult {
-	if val := v.data.Get(field); len(val) < min || len(val) > max {
+	trimmed := strings.TrimSpace(v.data.Get(field))
+	if length := utf8.RuneCountInString(trimmed); length < min || length > max {
 		return v.addLengthRangeError(field, min, max)
 	} else {
 		return validationOk
@@ -192,8 +410,7 @@ func (v *Validator) LengthRange(field string, min int, max int) *ValidationResul
 }
 
 func (v *Validator) addLengthRangeError(field string, min int, max int) *ValidationResult {
-	msg := fmt.Sprintf("%s must be between %d and %d characters long.", field, min, max)
-	return v.AddError(field, msg)
+	return v.addRuleError(RuleLengthRange, field, min, max)
 }
 
 // Equal will add an error to the Validator if data[field1]
@@ -201,8 +418,6 @@ func (v *Validator) addLengthRangeError(field string, min int, max int) *Validat
 func (v *Validator) Equal(field1 string, field2 string) *ValidationResult {
 	val1 := v.data.Get(field1)
 	val2 := v.data.Get(field2)
-	fmt.Println(val1)
-	fmt.Println(val2)
 	if val1 != val2 {
 		return v.addEqualError(field1, field2)
 	} else {
@@ -210,11 +425,21 @@ func (v *Validator) Equal(field1 string, field2 string) *ValidationResult {
 	}
 }
 
+// Matches is like Equal, but does not add an error to the Validator if
+// either data.Values[field1] or data.Values[field2] does not exist. Use
+// Require to check for existence. As with Equal, any error is associated
+// with field2.
+func (v *Validator) Matches(field1 string, field2 string) *ValidationResult {
+	if !v.data.KeyExists(field1) || !v.data.KeyExists(field2) {
+		return validationOk
+	}
+	return v.Equal(field1, field2)
+}
+
 func (v *Validator) addEqualError(field1 string, field2 string) *ValidationResult {
 	// note: "match" is a more natural colloquial term than "be equal"
 	// not to be confused with "matching" a regular expression
-	msg := fmt.Sprintf("%s and %s must match.", field1, field2)
-	return v.AddError(field2, msg)
+	return v.addRuleError(RuleEqual, field2, field1)
 }
 
 // Match will add an error to the Validator if data.Values[field] does
@@ -227,14 +452,47 @@ func (v *Validator) Match(field string, regex *regexp.Regexp) *ValidationResult
 	}
 }
 
-// Match will add an error to the Validator if data.Values[field] does
-// not match the pattern.
-func (v *Validator) MatchString(field string, pattern string) *ValidationResult {
-	if v.data.Get(field) != pattern {
-		return v.addMatchError(field)
-	} else {
+// MatchString will add an error to the Validator if data.Values[field] does
+// not match the regular expression pattern. If pattern cannot be compiled, it
+// returns the error from regexp.Compile and does not add an error to the
+// Validator. If you are checking many values against the same pattern, compile
+// it once and use Match instead.
+func (v *Validator) MatchString(field string, pattern string) (*ValidationResult, error) {
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return v.Match(field, regex), nil
+}
+
+// Disallow will add an error to the Validator if the first element of
+// data.Values[field] contains any of the characters in chars. For example,
+// Disallow("comment", "<>") rejects angle brackets in a plain text field. If
+// data.Values[field] does not exist, it does not add an error to the
+// Validator.
+func (v *Validator) Disallow(field string, chars string) *ValidationResult {
+	if !v.data.KeyExists(field) {
+		return validationOk
+	}
+	if i := strings.IndexAny(v.data.Get(field), chars); i >= 0 {
+		r, _ := utf8.DecodeRuneInString(v.data.Get(field)[i:])
+		return v.addRuleError(RuleDisallow, field, r)
+	}
+	return validationOk
+}
+
+// DisallowPattern is like Disallow, but will add an error to the Validator
+// if any part of the first element of data.Values[field] matches the regular
+// expression regex. If data.Values[field] does not exist, it does not add an
+// error to the Validator.
+func (v *Validator) DisallowPattern(field string, regex *regexp.Regexp) *ValidationResult {
+	if !v.data.KeyExists(field) {
 		return validationOk
 	}
+	if regex.MatchString(v.data.Get(field)) {
+		return v.addRuleError(RuleDisallowPattern, field)
+	}
+	return validationOk
 }
 
 // MatchEmail will add an error to the Validator if data.Values[field]
@@ -244,16 +502,169 @@ func (v *Validator) MatchEmail(field string) *ValidationResult {
 	return v.Match(field, regex)
 }
 
+// Email will add an error to the Validator if data.Values[field] is
+// not a valid email address as defined by mail.ParseAddress. This
+// includes addresses with a display name, such as "Bob <bob@example.com>".
+// If data.Values[field] does not exist or is empty, it does not add an
+// error to the Validator. Use Require to check for existence.
+func (v *Validator) Email(field string) *ValidationResult {
+	val := v.data.Get(field)
+	if val == "" {
+		return validationOk
+	}
+	if _, err := mail.ParseAddress(val); err != nil {
+		return v.addEmailError(field)
+	}
+	return validationOk
+}
+
+func (v *Validator) addEmailError(field string) *ValidationResult {
+	return v.addRuleError(RuleEmail, field)
+}
+
+// URL will add an error to the Validator if data.Values[field] is not
+// an absolute http or https URL, as parsed by url.ParseRequestURI.
+// Relative URLs and other schemes (e.g. "javascript:" or "ftp:") are
+// rejected. If data.Values[field] does not exist or is empty, it does
+// not add an error to the Validator.
+func (v *Validator) URL(field string) *ValidationResult {
+	val := v.data.Get(field)
+	if val == "" {
+		return validationOk
+	}
+	u, err := url.ParseRequestURI(val)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return v.addURLError(field)
+	}
+	return validationOk
+}
+
+func (v *Validator) addURLError(field string) *ValidationResult {
+	return v.addRuleError(RuleURL, field)
+}
+
+// IP will add an error to the Validator if data.Values[field] is not a
+// valid IPv4 or IPv6 address, as parsed by net.ParseIP. If
+// data.Values[field] does not exist or is empty, it does not add an error
+// to the Validator.
+func (v *Validator) IP(field string) *ValidationResult {
+	val := v.data.Get(field)
+	if val == "" {
+		return validationOk
+	}
+	if net.ParseIP(val) == nil {
+		return v.addRuleError(RuleIP, field)
+	}
+	return validationOk
+}
+
+// CIDR will add an error to the Validator if data.Values[field] is not a
+// valid IPv4 or IPv6 address and prefix length in CIDR notation, such as
+// "10.0.0.0/8", as parsed by net.ParseCIDR. If data.Values[field] does not
+// exist or is empty, it does not add an error to the Validator.
+func (v *Validator) CIDR(field string) *ValidationResult {
+	val := v.data.Get(field)
+	if val == "" {
+		return validationOk
+	}
+	if _, _, err := net.ParseCIDR(val); err != nil {
+		return v.addRuleError(RuleCIDR, field)
+	}
+	return validationOk
+}
+
 func (v *Validator) addMatchError(field string) *ValidationResult {
-	msg := fmt.Sprintf("%s must be correctly formatted.", field)
-	return v.AddError(field, msg)
+	return v.addRuleError(RuleMatch, field)
+}
+
+var (
+	slugRegex         = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+	alphanumericRegex = regexp.MustCompile(`^[a-zA-Z0-9]+$`)
+	hexadecimalRegex  = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+	numericRegex      = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// Slug will add an error to the Validator if the first element of
+// data.Values[field] is not a slug, i.e. lowercase letters and digits
+// separated by single dashes, such as "my-first-post". If
+// data.Values[field] does not exist, it does not add an error to the
+// Validator.
+func (v *Validator) Slug(field string) *ValidationResult {
+	return v.format(field, slugRegex, RuleSlug)
+}
+
+// Alphanumeric will add an error to the Validator if the first element of
+// data.Values[field] contains anything other than ASCII letters and digits.
+// If data.Values[field] does not exist, it does not add an error to the
+// Validator.
+func (v *Validator) Alphanumeric(field string) *ValidationResult {
+	return v.format(field, alphanumericRegex, RuleAlphanumeric)
+}
+
+// Hexadecimal will add an error to the Validator if the first element of
+// data.Values[field] contains anything other than hexadecimal digits (upper
+// or lower case). If data.Values[field] does not exist, it does not add an
+// error to the Validator.
+func (v *Validator) Hexadecimal(field string) *ValidationResult {
+	return v.format(field, hexadecimalRegex, RuleHexadecimal)
+}
+
+// Numeric will add an error to the Validator if the first element of
+// data.Values[field] contains anything other than the digits 0-9. Unlike
+// IsInt, signs are not allowed and there is no limit on length, so it is
+// suitable for values such as zip codes and account numbers. If
+// data.Values[field] does not exist, it does not add an error to the
+// Validator.
+func (v *Validator) Numeric(field string) *ValidationResult {
+	return v.format(field, numericRegex, RuleNumeric)
+}
+
+func (v *Validator) format(field string, regex *regexp.Regexp, rule string) *ValidationResult {
+	if !v.data.KeyExists(field) {
+		return validationOk
+	}
+	if !regex.MatchString(v.data.Get(field)) {
+		return v.addRuleError(rule, field)
+	}
+	return validationOk
+}
+
+// OneOf will add an error to the Validator if the first element of
+// data.Values[field] is not exactly equal to one of allowed. If
+// data.Values[field] does not exist, it does not add an error to the
+// Validator.
+func (v *Validator) OneOf(field string, allowed ...string) *ValidationResult {
+	return v.oneOf(field, allowed, func(a, b string) bool { return a == b })
+}
+
+// OneOfFold is like OneOf, but compares values case-insensitively (see
+// strings.EqualFold).
+func (v *Validator) OneOfFold(field string, allowed ...string) *ValidationResult {
+	return v.oneOf(field, allowed, strings.EqualFold)
+}
+
+func (v *Validator) oneOf(field string, allowed []string, equal func(a, b string) bool) *ValidationResult {
+	if !v.data.KeyExists(field) {
+		return validationOk
+	}
+	val := v.data.Get(field)
+	for _, choice := range allowed {
+		if equal(val, choice) {
+			return validationOk
+		}
+	}
+	return v.addOneOfError(field, allowed)
+}
+
+func (v *Validator) addOneOfError(field string, allowed []string) *ValidationResult {
+	return v.addRuleError(RuleOneOf, field, allowed)
 }
 
 // TypeInt will add an error to the Validator if the first
 // element of data.Values[field] cannot be converted to an int.
 func (v *Validator) TypeInt(field string) *ValidationResult {
 	if _, err := strconv.Atoi(v.data.Get(field)); err != nil {
-		return v.addTypeError(field, "integer")
+		return v.addTypeError(field, RuleTypeInt)
 	} else {
 		return validationOk
 	}
@@ -264,7 +675,7 @@ func (v *Validator) TypeInt(field string) *ValidationResult {
 func (v *Validator) TypeFloat(field string) *ValidationResult {
 	if _, err := strconv.ParseFloat(v.data.Get(field), 64); err != nil {
 		// note: "number" is a more natural colloquial term than "float"
-		return v.addTypeError(field, "number")
+		return v.addTypeError(field, RuleTypeFloat)
 	} else {
 		return validationOk
 	}
@@ -275,47 +686,79 @@ func (v *Validator) TypeFloat(field string) *ValidationResult {
 func (v *Validator) TypeBool(field string) *ValidationResult {
 	if _, err := strconv.ParseBool(v.data.Get(field)); err != nil {
 		// note: "true or false" is a more natural colloquial term than "bool"
-		return v.addTypeError(field, "true or false")
+		return v.addTypeError(field, RuleTypeBool)
 	} else {
 		return validationOk
 	}
 }
 
-func (v *Validator) addTypeError(field string, typ string) *ValidationResult {
-	article := "a"
-	if strings.Contains("aeiou", string(typ[0])) {
-		article = "an"
+// IsInt is like TypeInt, but does not add an error to the Validator
+// if data.Values[field] does not exist. Use Require to check for
+// existence.
+func (v *Validator) IsInt(field string) *ValidationResult {
+	if !v.data.KeyExists(field) {
+		return validationOk
+	}
+	return v.TypeInt(field)
+}
+
+// IsFloat is like TypeFloat, but does not add an error to the Validator
+// if data.Values[field] does not exist. Use Require to check for
+// existence.
+func (v *Validator) IsFloat(field string) *ValidationResult {
+	if !v.data.KeyExists(field) {
+		return validationOk
 	}
-	msg := fmt.Sprintf("%s must be %s %s", field, article, typ)
-	return v.AddError(field, msg)
+	return v.TypeFloat(field)
+}
+
+func (v *Validator) addTypeError(field string, rule string) *ValidationResult {
+	return v.addRuleError(rule, field)
 }
 
 // Greater will add an error to the Validator if the first
 // element of data.Values[field] is not greater than value or if the first
 // element of data.Values[field] cannot be converted to a number.
 func (v *Validator) Greater(field string, value float64) *ValidationResult {
-	return v.inequality(field, value, greater, "greater than")
+	return v.inequality(field, value, greater, RuleGreater)
 }
 
 // GreaterOrEqual will add an error to the Validator if the first
 // element of data.Values[field] is not greater than or equal to value or if
 // the first element of data.Values[field] cannot be converted to a number.
 func (v *Validator) GreaterOrEqual(field string, value float64) *ValidationResult {
-	return v.inequality(field, value, greaterOrEqual, "greater than or equal to")
+	return v.inequality(field, value, greaterOrEqual, RuleGreaterOrEqual)
 }
 
 // Less will add an error to the Validator if the first
 // element of data.Values[field] is not less than value or if the first
 // element of data.Values[field] cannot be converted to a number.
 func (v *Validator) Less(field string, value float64) *ValidationResult {
-	return v.inequality(field, value, less, "less than")
+	return v.inequality(field, value, less, RuleLess)
 }
 
 // LessOrEqual will add an error to the Validator if the first
 // element of data.Values[field] is not less than or equal to value or if
 // the first element of data.Values[field] cannot be converted to a number.
 func (v *Validator) LessOrEqual(field string, value float64) *ValidationResult {
-	return v.inequality(field, value, lessOrEqual, "less than or equal to")
+	return v.inequality(field, value, lessOrEqual, RuleLessOrEqual)
+}
+
+// GreaterThanField will add an error to the Validator if the first element
+// of data.Values[field] is not greater than the first element of
+// data.Values[otherField], or if either cannot be converted to a number. It is
+// useful for ranges submitted as two fields, e.g. a minimum and maximum price.
+// If either field does not exist, it does not add an error to the Validator.
+func (v *Validator) GreaterThanField(field string, otherField string) *ValidationResult {
+	return v.fieldInequality(field, otherField, greater, RuleGreaterField)
+}
+
+// GreaterOrEqualField will add an error to the Validator if the first element
+// of data.Values[field] is not greater than or equal to the first element of
+// data.Values[otherField], or if either cannot be converted to a number. If
+// either field does not exist, it does not add an error to the Validator.
+func (v *Validator) GreaterOrEqualField(field string, otherField string) *ValidationResult {
+	return v.fieldInequality(field, otherField, greaterOrEqual, RuleGreaterOrEqualField)
 }
 
 type conditional func(given float64, target float64) bool
@@ -336,19 +779,380 @@ var lessOrEqual conditional = func(given float64, target float64) bool {
 	return given <= target
 }
 
-func (v *Validator) inequality(field string, value float64, condition conditional, explanation string) *ValidationResult {
+func (v *Validator) inequality(field string, value float64, condition conditional, rule string) *ValidationResult {
 	if valFloat, err := strconv.ParseFloat(v.data.Get(field), 64); err != nil {
 		// note: "number" is a more natural colloquial term than "float"
-		return v.addTypeError(field, "number")
+		return v.addTypeError(field, RuleTypeFloat)
 	} else {
 		if !condition(valFloat, value) {
-			return v.AddError(field, fmt.Sprintf("%s must be %s %f.", field, explanation, value))
+			return v.addRuleError(rule, field, value)
 		} else {
 			return validationOk
 		}
 	}
 }
 
+func (v *Validator) fieldInequality(field string, otherField string, condition conditional, rule string) *ValidationResult {
+	if !v.data.KeyExists(field) || !v.data.KeyExists(otherField) {
+		return validationOk
+	}
+	valFloat, err := strconv.ParseFloat(v.data.Get(field), 64)
+	if err != nil {
+		return v.addTypeError(field, RuleTypeFloat)
+	}
+	otherFloat, err := strconv.ParseFloat(v.data.Get(otherField), 64)
+	if err != nil {
+		return v.addTypeError(otherField, RuleTypeFloat)
+	}
+	if !condition(valFloat, otherFloat) {
+		return v.addRuleError(rule, field, otherField)
+	}
+	return validationOk
+}
+
+// IntRange will add an error to the Validator if the first element of
+// data.Values[field] is less than min or greater than max (inclusive), or
+// if it cannot be converted to an int. If data.Values[field] does not exist,
+// it does not add an error to the Validator.
+func (v *Validator) IntRange(field string, min int, max int) *ValidationResult {
+	if !v.data.KeyExists(field) {
+		return validationOk
+	}
+	val, err := strconv.Atoi(v.data.Get(field))
+	if err != nil {
+		return v.addTypeError(field, RuleTypeInt)
+	}
+	if val < min || val > max {
+		return v.addIntRangeError(field, min, max)
+	}
+	return validationOk
+}
+
+func (v *Validator) addIntRangeError(field string, min int, max int) *ValidationResult {
+	return v.addRuleError(RuleIntRange, field, min, max)
+}
+
+// FloatRange will add an error to the Validator if the first element of
+// data.Values[field] is less than min or greater than max (inclusive), or
+// if it cannot be converted to a finite number ("NaN" and "Inf" are not
+// allowed). If data.Values[field] does not exist, it does not add an error
+// to the Validator.
+func (v *Validator) FloatRange(field string, min float64, max float64) *ValidationResult {
+	if !v.data.KeyExists(field) {
+		return validationOk
+	}
+	val, err := strconv.ParseFloat(v.data.Get(field), 64)
+	if err != nil || math.IsNaN(val) || math.IsInf(val, 0) {
+		return v.addTypeError(field, RuleTypeFloat)
+	}
+	if val < min || val > max {
+		return v.addFloatRangeError(field, min, max)
+	}
+	return validationOk
+}
+
+func (v *Validator) addFloatRangeError(field string, min float64, max float64) *ValidationResult {
+	return v.addRuleError(RuleFloatRange, field, min, max)
+}
+
+// DateRange will add an error to the Validator if the first element of
+// data.Values[field] is before min or after max, or if it cannot be parsed
+// using layout (see time.Parse). If data.Values[field] does not exist or
+// is empty, it does not add an error to the Validator.
+func (v *Validator) DateRange(field string, layout string, min time.Time, max time.Time) *ValidationResult {
+	date, err := v.data.GetTime(field, layout)
+	if err != nil {
+		return v.addDateError(field)
+	}
+	if date.IsZero() {
+		return validationOk
+	}
+	if date.Before(min) || date.After(max) {
+		return v.addDateRangeError(field, layout, min, max)
+	}
+	return validationOk
+}
+
+func (v *Validator) addDateError(field string) *ValidationResult {
+	return v.addRuleError(RuleDate, field)
+}
+
+func (v *Validator) addDateRangeError(field string, layout string, min time.Time, max time.Time) *ValidationResult {
+	return v.addRuleError(RuleDateRange, field, min.Format(layout), max.Format(layout))
+}
+
+// CountBetween will add an error to the Validator if the number of
+// values in data.Values[field] is less than min or greater than max
+// (inclusive). This checks how many values were provided, e.g. for a
+// group of checkboxes, not the values themselves. If data.Values[field]
+// does not exist, it is considered to have zero values.
+func (v *Validator) CountBetween(field string, min int, max int) *ValidationResult {
+	if count := len(v.data.Values[field]); count < min || count > max {
+		return v.addRuleError(RuleCountBetween, field, min, max)
+	}
+	return validationOk
+}
+
+// CountExactly will add an error to the Validator if the number of
+// values in data.Values[field] is not exactly n.
+func (v *Validator) CountExactly(field string, n int) *ValidationResult {
+	if len(v.data.Values[field]) != n {
+		return v.addRuleError(RuleCountExactly, field, n)
+	}
+	return validationOk
+}
+
+// CountAtLeast will add an error to the Validator if the number of
+// values in data.Values[field] is less than n.
+func (v *Validator) CountAtLeast(field string, n int) *ValidationResult {
+	if len(v.data.Values[field]) < n {
+		return v.addRuleError(RuleCountAtLeast, field, n)
+	}
+	return validationOk
+}
+
+// Subset will add an error to the Validator if any of the values in
+// data.Values[field] is not exactly equal to one of allowed, and another if
+// the number of values is less than min or greater than max (inclusive), as
+// with CountBetween. This is useful for a group of checkboxes or a multiple
+// select with a fixed set of choices. At most one error is added for values
+// which are not allowed, no matter how many there are. If data.Values[field]
+// does not exist, it is considered to have zero values. If both checks fail,
+// the result for the first is returned.
+func (v *Validator) Subset(field string, allowed []string, min int, max int) *ValidationResult {
+	result := validationOk
+	for _, val := range v.data.Values[field] {
+		if !containsString(allowed, val) {
+			result = v.addOneOfError(field, allowed)
+			break
+		}
+	}
+	if countResult := v.CountBetween(field, min, max); result == validationOk {
+		result = countResult
+	}
+	return result
+}
+
+// Unique will add an error to the Validator if data.Values[field] contains
+// the same value more than once, e.g. for a tag input which was submitted
+// twice. Values are compared exactly. If data.Values[field] does not exist or
+// has only one value, it does not add an error to the Validator.
+func (v *Validator) Unique(field string) *ValidationResult {
+	return v.unique(field, func(val string) string { return val })
+}
+
+// UniqueFold is like Unique, but compares values case-insensitively, so
+// "Go" and "go" are considered duplicates. Values are compared with the same
+// case folding as strings.EqualFold, as used by OneOfFold and GetFold.
+func (v *Validator) UniqueFold(field string) *ValidationResult {
+	return v.unique(field, foldString)
+}
+
+// foldString returns s with each rune replaced by the smallest rune which is
+// equivalent to it under simple case folding. Two strings are equal after
+// foldString if and only if strings.EqualFold reports that they are equal.
+func foldString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		min := r
+		for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+			if f < min {
+				min = f
+			}
+		}
+		b.WriteRune(min)
+	}
+	return b.String()
+}
+
+func (v *Validator) unique(field string, normalize func(string) string) *ValidationResult {
+	seen := map[string]bool{}
+	for _, val := range v.data.Values[field] {
+		key := normalize(val)
+		if seen[key] {
+			return v.addRuleError(RuleUnique, field, val)
+		}
+		seen[key] = true
+	}
+	return validationOk
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// PasswordOpts specifies the criteria checked by Validator.Password.
+type PasswordOpts struct {
+	// MinLength is the minimum number of characters. Unlike
+	// Validator.MinLength, whitespace is not trimmed.
+	MinLength int
+	// RequireUpper, RequireLower, RequireDigit and RequireSymbol require
+	// at least one uppercase letter, lowercase letter, digit, or
+	// symbol (including punctuation) respectively.
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+// Password will add an error to the Validator for each criterion in opts
+// which data.Values[field] does not meet, so that users know exactly what
+// is missing. If there are any errors, the returned ValidationResult is
+// the one for the first error. If data.Values[field] does not exist, it
+// does not add an error to the Validator. Use Require to check for
+// existence.
+func (v *Validator) Password(field string, opts PasswordOpts) *ValidationResult {
+	if !v.data.KeyExists(field) {
+		return validationOk
+	}
+	val := v.data.Get(field)
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range val {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+	failed := []string{}
+	if utf8.RuneCountInString(val) < opts.MinLength {
+		failed = append(failed, v.message(RuleMinLength, field, opts.MinLength))
+	}
+	if opts.RequireUpper && !hasUpper {
+		failed = append(failed, v.message(RulePasswordUpper, field))
+	}
+	if opts.RequireLower && !hasLower {
+		failed = append(failed, v.message(RulePasswordLower, field))
+	}
+	if opts.RequireDigit && !hasDigit {
+		failed = append(failed, v.message(RulePasswordDigit, field))
+	}
+	if opts.RequireSymbol && !hasSymbol {
+		failed = append(failed, v.message(RulePasswordSymbol, field))
+	}
+	result := validationOk
+	for i, msg := range failed {
+		if r := v.AddError(field, msg); i == 0 {
+			result = r
+		}
+	}
+	return result
+}
+
+// Sanitize replaces the first element of data.Values[field] with the result
+// of calling fn on it, so that normalization (e.g. stripping non-digits from
+// a phone number) can happen alongside validation. Note that Sanitize mutates
+// the underlying Data, so later rules and any calls to Get see the new value.
+// Any other values for field are left unchanged. If data.Values[field] does
+// not exist, or v was returned by When and the condition was not met, fn is
+// not called.
+func (v *Validator) Sanitize(field string, fn func(value string) string) {
+	if !v.data.KeyExists(field) || len(v.data.Values[field]) == 0 || v.skipped() {
+		return
+	}
+	v.data.Values[field][0] = fn(v.data.Values[field][0])
+}
+
+// Luhn will add an error to the Validator if the first element of
+// data.Values[field] does not pass the Luhn checksum used by credit card
+// numbers, or if it contains anything other than digits, spaces, and dashes.
+// Spaces and dashes are ignored, so "4111 1111 1111 1111" is valid. If
+// data.Values[field] does not exist, it does not add an error to the Validator.
+func (v *Validator) Luhn(field string) *ValidationResult {
+	if !v.data.KeyExists(field) {
+		return validationOk
+	}
+	var digits []int
+	for _, r := range v.data.Get(field) {
+		switch {
+		case r == ' ' || r == '-':
+			continue
+		case r >= '0' && r <= '9':
+			digits = append(digits, int(r-'0'))
+		default:
+			return v.addLuhnError(field)
+		}
+	}
+	if len(digits) < 2 {
+		return v.addLuhnError(field)
+	}
+	sum := 0
+	for i := range digits {
+		digit := digits[len(digits)-1-i]
+		if i%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+	if sum%10 != 0 {
+		return v.addLuhnError(field)
+	}
+	return validationOk
+}
+
+func (v *Validator) addLuhnError(field string) *ValidationResult {
+	return v.addRuleError(RuleLuhn, field)
+}
+
+// Phone will add an error to the Validator if the first element of
+// data.Values[field] is not a plausible phone number. Spaces, dashes, dots,
+// and parentheses are ignored, and a single leading "+" is allowed, so both
+// "+14155550123" and "(415) 555-0123" are valid. The remaining characters
+// must be 7 to 15 digits. This is only a basic sanity check, not a full
+// validation of the number. If data.Values[field] does not exist, it does
+// not add an error to the Validator.
+func (v *Validator) Phone(field string) *ValidationResult {
+	if !v.data.KeyExists(field) {
+		return validationOk
+	}
+	val := strings.TrimSpace(v.data.Get(field))
+	val = strings.TrimPrefix(val, "+")
+	digits := 0
+	for _, r := range val {
+		switch {
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+			continue
+		case r >= '0' && r <= '9':
+			digits++
+		default:
+			return v.addPhoneError(field)
+		}
+	}
+	if digits < 7 || digits > 15 {
+		return v.addPhoneError(field)
+	}
+	return validationOk
+}
+
+func (v *Validator) addPhoneError(field string) *ValidationResult {
+	return v.addRuleError(RulePhone, field)
+}
+
+// Custom calls fn with the first element of data.Values[field] and will
+// add an error to the Validator with the message returned by fn if fn
+// returns false. fn is always called, even if data.Values[field] does not
+// exist, in which case it is called with an empty string. This allows
+// fn to decide whether or not the field is optional.
+func (v *Validator) Custom(field string, fn func(value string) (ok bool, msg string)) *ValidationResult {
+	if ok, msg := fn(v.data.Get(field)); !ok {
+		return v.AddError(field, msg)
+	}
+	return validationOk
+}
+
 // AcceptFileExts will add an error to the Validator if the extension
 // of the file identified by field is not in exts. exts should be one ore more
 // allowed file extensions, not including the preceding ".". If the file does not
@@ -368,30 +1172,32 @@ func (v *Validator) AcceptFileExts(field string, exts ...string) *ValidationResu
 }
 
 func (v *Validator) addFileExtError(field string, gotExt string, allowedExts ...string) *ValidationResult {
-	msg := fmt.Sprintf("The file extension %s is not allowed. Allowed extensions include: ", gotExt)
+	return v.addRuleError(RuleFileExt, field, gotExt, allowedExts)
+}
 
-	// Append each allowed extension to the message, in a human-readable list
-	// e.g. "x, y, and z"
-	for i, ext := range allowedExts {
-		if i == len(allowedExts)-1 {
+// humanList joins items into a human-readable list, e.g. "x, y, and z".
+func humanList(items []string) string {
+	list := ""
+	for i, item := range items {
+		if i == len(items)-1 {
 			// special case for the last element
-			switch len(allowedExts) {
+			switch len(items) {
 			case 1:
-				msg += ext
+				list += item
 			default:
-				msg += fmt.Sprintf("and %s", ext)
+				list += fmt.Sprintf("and %s", item)
 			}
 		} else {
 			// default case for middle elements
 			// we only reach here if there is at least
 			// one element
-			switch len(allowedExts) {
+			switch len(items) {
 			case 2:
-				msg += fmt.Sprintf("%s ", ext)
+				list += fmt.Sprintf("%s ", item)
 			default:
-				msg += fmt.Sprintf("%s, ", ext)
+				list += fmt.Sprintf("%s, ", item)
 			}
 		}
 	}
-	return v.AddError(field, msg)
+	return list
 }
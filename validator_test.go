@@ -6,13 +6,18 @@ package forms
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"math"
 	"mime/multipart"
 	"net/http"
 	"net/url"
+	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestCustomMessage(t *testing.T) {
@@ -41,6 +46,82 @@ func TestCustomField(t *testing.T) {
 	}
 }
 
+func TestOrderedErrors(t *testing.T) {
+	data := newData()
+	val := data.Validator()
+	val.Require("username")
+	val.Require("email")
+	val.Require("password").Message("Choose a password.")
+	expected := []FieldError{
+		{Field: "username", Message: "username is required."},
+		{Field: "email", Message: "email is required."},
+		{Field: "password", Message: "Choose a password."},
+	}
+	if got := val.OrderedErrors(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v but got %v", expected, got)
+	}
+}
+
+func TestErr(t *testing.T) {
+	data := newData()
+	data.Add("name", "Bob")
+	val := data.Validator()
+	val.Require("name")
+	if err := val.Err(); err != nil {
+		t.Errorf("Expected Err to return nil but got: %v", err)
+	}
+
+	val.Require("age")
+	val.Require("color").Message("Pick a color!")
+	err := val.Err()
+	if err == nil {
+		t.Fatal("Expected Err to return an error but got nil.")
+	}
+	valErr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("Expected Err to return a *ValidationError but got %T.", err)
+	}
+	if expected := "age is required. Pick a color!"; valErr.Error() != expected {
+		t.Errorf(`Expected error message "%s" but got "%s"`, expected, valErr.Error())
+	}
+	gotJSON, jsonErr := json.Marshal(err)
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	expectedJSON := `{"errors":{"age":["age is required."],"color":["Pick a color!"]}}`
+	if string(gotJSON) != expectedJSON {
+		t.Errorf("Expected json %s but got %s", expectedJSON, string(gotJSON))
+	}
+}
+
+func TestWhen(t *testing.T) {
+	data := newData()
+	data.Add("account_type", "business")
+	val := data.Validator()
+	business := val.When("account_type", "business")
+	business.Require("company_name")
+	business.Require("tax_id").Message("Businesses must provide a tax id.")
+	val.When("account_type", "personal").Require("birthday")
+	if len(val.Messages()) != 2 {
+		t.Fatalf("Expected 2 validation errors but got %d: %v", len(val.Messages()), val.Messages())
+	}
+	if got := val.Fields(); !reflect.DeepEqual(got, []string{"company_name", "tax_id"}) {
+		t.Errorf("Expected errors for company_name and tax_id but got %v", got)
+	}
+	if got := val.Messages()[1]; got != "Businesses must provide a tax id." {
+		t.Errorf("Expected custom message on scoped rule but got: %s", got)
+	}
+
+	// Nested conditions should also write to the original validator
+	data.Add("country", "us")
+	val = data.Validator()
+	val.When("account_type", "business").When("country", "us").Require("ein")
+	val.When("account_type", "business").When("country", "ca").Require("bn")
+	if got := val.Fields(); !reflect.DeepEqual(got, []string{"ein"}) {
+		t.Errorf("Expected an error for ein only but got %v", got)
+	}
+}
+
 func TestRequire(t *testing.T) {
 	data := newData()
 	data.Add("name", "Bob")
@@ -61,6 +142,161 @@ func TestRequire(t *testing.T) {
 	}
 }
 
+func TestAccepted(t *testing.T) {
+	data := newData()
+	data.Add("checkbox", "on")
+	data.Add("yes", "YES")
+	data.Add("one", "1")
+	data.Add("declined", "no")
+	data.Add("empty", "")
+	val := data.Validator()
+	val.Accepted("checkbox")
+	val.Accepted("yes")
+	val.Accepted("one")
+	if val.HasErrors() {
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
+	}
+
+	val.Accepted("declined")
+	val.Accepted("empty")
+	val.Accepted("missing")
+	if fields := val.Fields(); !reflect.DeepEqual(fields, []string{"declined", "empty", "missing"}) {
+		t.Errorf("Expected errors on declined, empty, and missing but got errors on %v", fields)
+	}
+}
+
+func TestRequiredWith(t *testing.T) {
+	data := newData()
+	data.Add("street", "123 Main St")
+	data.Add("zip", "12345")
+	data.Add("blank", " ")
+	val := data.Validator()
+	val.RequiredWith("zip", "street")
+	val.RequiredWith("city", "missing", "blank")
+	val.RequiredWith("city")
+	if val.HasErrors() {
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
+	}
+
+	val.RequiredWith("city", "missing", "street")
+	val.RequiredWith("blank", "zip")
+	if fields := val.Fields(); !reflect.DeepEqual(fields, []string{"city", "blank"}) {
+		t.Errorf("Expected errors on city and blank but got errors on %v", fields)
+	}
+}
+
+func TestRequiredWithout(t *testing.T) {
+	data := newData()
+	data.Add("phone", "555-1234")
+	data.Add("email", "bob@example.com")
+	data.Add("blank", "")
+	val := data.Validator()
+	val.RequiredWithout("email", "phone", "missing")
+	val.RequiredWithout("fax", "phone", "email")
+	val.RequiredWithout("fax")
+	if val.HasErrors() {
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
+	}
+
+	val.RequiredWithout("fax", "phone", "missing")
+	val.RequiredWithout("blank", "missing")
+	if fields := val.Fields(); !reflect.DeepEqual(fields, []string{"fax", "blank"}) {
+		t.Errorf("Expected errors on fax and blank but got errors on %v", fields)
+	}
+}
+
+func TestNotBlank(t *testing.T) {
+	data := newData()
+	data.Add("name", "x")
+	data.Add("spaces", "   ")
+	data.Add("empty", "")
+	val := data.Validator()
+	val.NotBlank("name")
+	val.NotBlank("missing")
+	if val.HasErrors() {
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
+	}
+
+	val.NotBlank("spaces")
+	val.NotBlank("empty")
+	if fields := val.Fields(); !reflect.DeepEqual(fields, []string{"spaces", "empty"}) {
+		t.Errorf("Expected errors on spaces and empty but got errors on %v", fields)
+	}
+}
+
+func TestRequireFields(t *testing.T) {
+	data := newData()
+	data.Add("name", "Bob")
+	data.Add("color", "   ")
+
+	val := data.Validator()
+	val.RequireFields("name", "color", "age", "email")
+	errMap := val.ErrorMap()
+	if len(errMap) != 3 {
+		t.Errorf("Expected 3 fields with errors but got %d: %v", len(errMap), errMap)
+	}
+	for _, field := range []string{"color", "age", "email"} {
+		if _, found := errMap[field]; !found {
+			t.Errorf("Expected an error for %s but got none.", field)
+		}
+	}
+	if _, found := errMap["name"]; found {
+		t.Errorf("Expected no error for name but got %v", errMap["name"])
+	}
+}
+
+func TestAllowFields(t *testing.T) {
+	data := newData()
+	data.Add("name", "Bob")
+	data.Add("email", "bob@example.com")
+	data.Add("is_admin", "true")
+	data.Add("emial", "typo")
+
+	val := data.Validator()
+	val.AllowFields("name", "email", "age")
+	if got := val.Fields(); !reflect.DeepEqual(got, []string{"emial", "is_admin"}) {
+		t.Errorf("Expected errors for emial and is_admin but got %v", got)
+	}
+
+	val = data.Validator()
+	val.AllowFields("name", "email", "is_admin", "emial")
+	if val.HasErrors() {
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	body := "name=" + strings.Repeat("a", 95)
+	req, err := http.NewRequest("POST", "/", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	data, err := Parse(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := data.ContentLength(); got != 100 {
+		t.Fatalf("Expected ContentLength to be 100 but got %d", got)
+	}
+	val := data.Validator()
+	val.MaxBodySize(100)
+	if val.HasErrors() {
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
+	}
+	val.MaxBodySize(99).Field("form")
+	if fields := val.Fields(); len(fields) != 1 || fields[0] != "form" {
+		t.Errorf("Expected 1 error on form but got errors on %v", fields)
+	}
+
+	// A length which is unknown should not cause an error
+	val = CreateFromMap(map[string]string{"name": body}).Validator()
+	val.MaxBodySize(10)
+	if val.HasErrors() {
+		t.Errorf("Expected no errors for an unknown length but got errors: %v", val.Messages())
+	}
+}
+
 func TestRequireFile(t *testing.T) {
 	data := newData()
 	val := data.Validator()
@@ -134,7 +370,7 @@ func TestMinLength(t *testing.T) {
 	val.MinLength("one", 1)
 	val.MinLength("three", 3)
 	if val.HasErrors() {
-		t.Error("Expected no errors but got errors: %v", val.Messages())
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
 	}
 
 	val.MinLength("five", 5)
@@ -143,6 +379,36 @@ func TestMinLength(t *testing.T) {
 	}
 }
 
+func TestMinMaxLengthRunes(t *testing.T) {
+	data := newData()
+	data.Add("emoji", "😀😀😀")
+	data.Add("name", "Zoë")
+
+	// Each value is exactly three runes long, but more than three bytes
+	val := data.Validator()
+	val.MinLength("emoji", 3)
+	val.MaxLength("emoji", 3)
+	val.MinLength("name", 3)
+	val.MaxLength("name", 3)
+	if val.HasErrors() {
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
+	}
+
+	val.MinLength("emoji", 4)
+	val.MaxLength("name", 2)
+	if len(val.Messages()) != 2 {
+		t.Errorf("Expected 2 validation errors but got %d.", len(val.Messages()))
+	}
+
+	// Missing fields should be left to Require
+	val = data.Validator()
+	val.MinLength("missing", 3)
+	val.MaxLength("missing", 3)
+	if val.HasErrors() {
+		t.Errorf("Expected no errors for a missing field but got errors: %v", val.Messages())
+	}
+}
+
 func TestMaxLength(t *testing.T) {
 	data := newData()
 	data.Add("one", "A")
@@ -182,6 +448,28 @@ func TestLengthRange(t *testing.T) {
 	}
 }
 
+func TestLengthTrimmed(t *testing.T) {
+	data := newData()
+	data.Add("padded", " ab ")
+	data.Add("emoji", " 😀😀 ")
+
+	// Leading and trailing whitespace should not count towards the length
+	val := data.Validator()
+	val.MinLength("padded", 3)
+	val.LengthRange("padded", 3, 5)
+	if len(val.Messages()) != 2 {
+		t.Errorf("Expected 2 validation errors but got %d.", len(val.Messages()))
+	}
+
+	val = data.Validator()
+	val.MaxLength("padded", 2)
+	val.LengthRange("padded", 1, 2)
+	val.LengthRange("emoji", 2, 2)
+	if val.HasErrors() {
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
+	}
+}
+
 func TestEqual(t *testing.T) {
 	data := newData()
 	data.Add("password", "password123")
@@ -200,6 +488,28 @@ func TestEqual(t *testing.T) {
 	}
 }
 
+func TestMatches(t *testing.T) {
+	data := newData()
+	data.Add("password", "password123")
+	data.Add("password_confirm", "password123")
+	data.Add("nonMatching", "password1234")
+
+	val := data.Validator()
+	val.Matches("password", "password_confirm")
+	val.Matches("password", "missing")
+	val.Matches("missing", "password")
+	if val.HasErrors() {
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
+	}
+
+	val.Matches("password", "nonMatching")
+	if len(val.Messages()) != 1 {
+		t.Errorf("Expected 1 validation error but got %d.", len(val.Messages()))
+	} else if got := val.Fields()[0]; got != "nonMatching" {
+		t.Errorf("Expected error to be associated with nonMatching but got %s.", got)
+	}
+}
+
 func TestMatch(t *testing.T) {
 	data := newData()
 	data.Add("numeric", "123")
@@ -223,6 +533,35 @@ func TestMatch(t *testing.T) {
 	}
 }
 
+func TestMatchString(t *testing.T) {
+	data := newData()
+	data.Add("zip", "90210")
+	data.Add("not-zip", "9021")
+
+	val := data.Validator()
+	if _, err := val.MatchString("zip", "^[0-9]{5}$"); err != nil {
+		t.Error(err)
+	}
+	if val.HasErrors() {
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
+	}
+
+	if _, err := val.MatchString("not-zip", "^[0-9]{5}$"); err != nil {
+		t.Error(err)
+	}
+	if len(val.Messages()) != 1 {
+		t.Errorf("Expected 1 validation error but got %d.", len(val.Messages()))
+	}
+
+	val = data.Validator()
+	if _, err := val.MatchString("zip", "[0-9"); err == nil {
+		t.Error("Expected an error for an invalid pattern but got none.")
+	}
+	if val.HasErrors() {
+		t.Errorf("Expected no validation errors for an invalid pattern but got: %v", val.Messages())
+	}
+}
+
 func TestMatchEmail(t *testing.T) {
 	data := newData()
 	data.Add("email", "abc@example.com")
@@ -240,6 +579,202 @@ func TestMatchEmail(t *testing.T) {
 	}
 }
 
+func TestEmail(t *testing.T) {
+	data := newData()
+	data.Add("email", "a@b.com")
+	data.Add("named", "Bob <bob@x.com>")
+	data.Add("empty", "")
+	data.Add("not-email", "not-an-email")
+	val := data.Validator()
+	val.Email("email")
+	val.Email("named")
+	val.Email("empty")
+	val.Email("missing")
+	if val.HasErrors() {
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
+	}
+
+	val.Email("not-email")
+	if len(val.Messages()) != 1 {
+		t.Errorf("Expected 1 validation error but got %d.", len(val.Messages()))
+	} else if !strings.Contains(val.Messages()[0], "email address") {
+		t.Errorf("Expected message to mention email address but got: %s", val.Messages()[0])
+	}
+}
+
+func TestDisallow(t *testing.T) {
+	data := newData()
+	data.Add("plain", "ab")
+	data.Add("html", "a<b")
+	data.Add("unicode", "naïve")
+	data.Add("script", "javascript:alert(1)")
+	val := data.Validator()
+	val.Disallow("plain", "<>")
+	val.Disallow("missing", "<>")
+	val.DisallowPattern("plain", regexp.MustCompile(`(?i)javascript:`))
+	val.DisallowPattern("missing", regexp.MustCompile(`.`))
+	if val.HasErrors() {
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
+	}
+
+	val.Disallow("html", "<>")
+	val.Disallow("unicode", "ï")
+	val.DisallowPattern("script", regexp.MustCompile(`(?i)javascript:`))
+	if fields := val.Fields(); !reflect.DeepEqual(fields, []string{"html", "unicode", "script"}) {
+		t.Fatalf("Expected errors on html, unicode, and script but got errors on %v", fields)
+	}
+	if msg := val.Messages()[1]; !strings.Contains(msg, "'ï'") {
+		t.Errorf("Expected error to name the disallowed character but got: %s", msg)
+	}
+}
+
+func TestIPAndCIDR(t *testing.T) {
+	data := newData()
+	data.Add("v4", "192.168.0.1")
+	data.Add("v6", "2001:db8::1")
+	data.Add("badV4", "192.168.0.256")
+	data.Add("badV6", "2001:db8:::1")
+	data.Add("cidrV4", "10.0.0.0/8")
+	data.Add("cidrV6", "2001:db8::/32")
+	data.Add("badPrefix", "10.0.0.0/40")
+	data.Add("noPrefix", "10.0.0.0")
+	val := data.Validator()
+	val.IP("v4")
+	val.IP("v6")
+	val.IP("missing")
+	val.CIDR("cidrV4")
+	val.CIDR("cidrV6")
+	val.CIDR("missing")
+	if val.HasErrors() {
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
+	}
+
+	val.IP("badV4")
+	val.IP("badV6")
+	val.IP("cidrV4")
+	val.CIDR("badPrefix")
+	val.CIDR("noPrefix")
+	expected := []string{"badV4", "badV6", "cidrV4", "badPrefix", "noPrefix"}
+	if fields := val.Fields(); !reflect.DeepEqual(fields, expected) {
+		t.Errorf("Expected errors on %v but got errors on %v", expected, fields)
+	}
+}
+
+func TestFormats(t *testing.T) {
+	table := []struct {
+		rule    func(v *Validator, field string) *ValidationResult
+		name    string
+		valid   []string
+		invalid []string
+	}{
+		{
+			rule:    (*Validator).Slug,
+			name:    "Slug",
+			valid:   []string{"hello", "my-first-post", "v2"},
+			invalid: []string{"", "Hello", "double--dash", "-leading", "trailing-", "under_score"},
+		},
+		{
+			rule:    (*Validator).Alphanumeric,
+			name:    "Alphanumeric",
+			valid:   []string{"abc123", "ABC"},
+			invalid: []string{"", "abc 123", "abc-123", "ünïcode"},
+		},
+		{
+			rule:    (*Validator).Hexadecimal,
+			name:    "Hexadecimal",
+			valid:   []string{"deadBEEF", "0123456789"},
+			invalid: []string{"", "0x1f", "xyz"},
+		},
+		{
+			rule:    (*Validator).Numeric,
+			name:    "Numeric",
+			valid:   []string{"0", "00501", "12345678901234567890"},
+			invalid: []string{"", "-1", "1.5", "12a"},
+		},
+	}
+	for _, test := range table {
+		for _, value := range test.valid {
+			val := CreateFromMap(map[string]string{"field": value}).Validator()
+			test.rule(val, "field")
+			if val.HasErrors() {
+				t.Errorf("%s: expected %q to be valid but got errors: %v", test.name, value, val.Messages())
+			}
+		}
+		for _, value := range test.invalid {
+			val := CreateFromMap(map[string]string{"field": value}).Validator()
+			test.rule(val, "field")
+			if !val.HasErrors() {
+				t.Errorf("%s: expected %q to be invalid but got no errors", test.name, value)
+			}
+		}
+		val := newData().Validator()
+		test.rule(val, "missing")
+		if val.HasErrors() {
+			t.Errorf("%s: expected missing field to be skipped but got errors: %v", test.name, val.Messages())
+		}
+	}
+}
+
+func TestOneOf(t *testing.T) {
+	data := newData()
+	data.Add("country", "us")
+	data.Add("upper", "US")
+	val := data.Validator()
+	val.OneOf("country", "us", "ca", "mx")
+	val.OneOf("missing", "us", "ca", "mx")
+	if val.HasErrors() {
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
+	}
+
+	val.OneOf("upper", "us", "ca", "mx")
+	if len(val.Messages()) != 1 {
+		t.Errorf("Expected 1 validation error but got %d.", len(val.Messages()))
+	} else if msg := val.Messages()[0]; !strings.Contains(msg, "us, ca, and mx") {
+		t.Errorf("Expected message to list the allowed values but got: %s", msg)
+	}
+}
+
+func TestOneOfFold(t *testing.T) {
+	data := newData()
+	data.Add("country", "US")
+	data.Add("invalid", "uk")
+	val := data.Validator()
+	val.OneOfFold("country", "us", "ca")
+	if val.HasErrors() {
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
+	}
+
+	val.OneOfFold("invalid", "us", "ca")
+	if len(val.Messages()) != 1 {
+		t.Errorf("Expected 1 validation error but got %d.", len(val.Messages()))
+	}
+}
+
+func TestURL(t *testing.T) {
+	data := newData()
+	data.Add("https", "https://example.com")
+	data.Add("http", "http://example.com/path?q=1")
+	data.Add("ftp", "ftp://x")
+	data.Add("not-url", "not a url")
+	data.Add("javascript", "javascript:alert(1)")
+	data.Add("relative", "/just/a/path")
+	val := data.Validator()
+	val.URL("https")
+	val.URL("http")
+	val.URL("missing")
+	if val.HasErrors() {
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
+	}
+
+	val.URL("ftp")
+	val.URL("not-url")
+	val.URL("javascript")
+	val.URL("relative")
+	if len(val.Messages()) != 4 {
+		t.Errorf("Expected 4 validation errors but got %d.", len(val.Messages()))
+	}
+}
+
 func TestTypeInt(t *testing.T) {
 	data := newData()
 	data.Add("age", "23")
@@ -292,6 +827,29 @@ func TestTypeBool(t *testing.T) {
 	}
 }
 
+func TestIsIntAndIsFloat(t *testing.T) {
+	data := newData()
+	data.Add("int", "42")
+	data.Add("float", "4.2")
+	data.Add("word", "x")
+	val := data.Validator()
+	val.IsInt("int")
+	val.IsFloat("int")
+	val.IsFloat("float")
+	val.IsInt("missing")
+	val.IsFloat("missing")
+	if val.HasErrors() {
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
+	}
+
+	val.IsInt("float")
+	val.IsInt("word")
+	val.IsFloat("word")
+	if len(val.Messages()) != 3 {
+		t.Errorf("Expected 3 validation errors but got %d.", len(val.Messages()))
+	}
+}
+
 func TestGreater(t *testing.T) {
 	data := newData()
 	data.Add("one", "1")
@@ -364,6 +922,417 @@ func TestLessOrEqual(t *testing.T) {
 	}
 }
 
+func TestGreaterThanField(t *testing.T) {
+	data := newData()
+	data.Add("start", "10")
+	data.Add("end", "20")
+	data.Add("same", "10")
+	data.Add("word", "ten")
+	val := data.Validator()
+	val.GreaterThanField("end", "start")
+	val.GreaterOrEqualField("end", "start")
+	val.GreaterOrEqualField("same", "start")
+	val.GreaterThanField("missing", "start")
+	val.GreaterThanField("end", "missing")
+	if val.HasErrors() {
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
+	}
+
+	val = data.Validator()
+	val.GreaterThanField("start", "end")
+	val.GreaterThanField("same", "start")
+	val.GreaterOrEqualField("start", "end")
+	if len(val.Messages()) != 3 {
+		t.Errorf("Expected 3 validation errors but got %d.", len(val.Messages()))
+	}
+	for _, field := range val.Fields() {
+		if field != "start" && field != "same" {
+			t.Errorf("Expected errors on the compared field but got one on %s", field)
+		}
+	}
+
+	val = data.Validator()
+	val.GreaterThanField("word", "start")
+	val.GreaterThanField("end", "word")
+	if fields := val.Fields(); len(fields) != 2 || fields[0] != "word" || fields[1] != "word" {
+		t.Fatalf("Expected 2 type errors on word but got errors on %v", fields)
+	}
+	if msg := val.Messages()[0]; !strings.Contains(msg, "number") {
+		t.Errorf("Expected type error to mention number but got: %s", msg)
+	}
+}
+
+func TestIntRange(t *testing.T) {
+	data := newData()
+	data.Add("min", "1")
+	data.Add("max", "10")
+	data.Add("below", "0")
+	data.Add("above", "11")
+	data.Add("word", "ten")
+	val := data.Validator()
+	val.IntRange("min", 1, 10)
+	val.IntRange("max", 1, 10)
+	val.IntRange("missing", 1, 10)
+	if val.HasErrors() {
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
+	}
+
+	val.IntRange("below", 1, 10)
+	val.IntRange("above", 1, 10)
+	if len(val.Messages()) != 2 {
+		t.Errorf("Expected 2 validation errors but got %d.", len(val.Messages()))
+	}
+
+	val = data.Validator()
+	val.IntRange("word", 1, 10)
+	val.IntRange("above", 1, 10)
+	if len(val.Messages()) != 2 {
+		t.Fatalf("Expected 2 validation errors but got %d.", len(val.Messages()))
+	}
+	if typeMsg, rangeMsg := val.Messages()[0], val.Messages()[1]; typeMsg == rangeMsg {
+		t.Errorf("Expected distinct messages for type and range errors but both were: %s", typeMsg)
+	} else if !strings.Contains(typeMsg, "integer") {
+		t.Errorf("Expected type error to mention integer but got: %s", typeMsg)
+	}
+}
+
+func TestFloatRange(t *testing.T) {
+	data := newData()
+	data.Add("min", "0")
+	data.Add("max", "5.0")
+	data.Add("middle", "2.5")
+	data.Add("below", "-0.1")
+	data.Add("above", "5.01")
+	data.Add("word", "five")
+	data.Add("nan", "NaN")
+	data.Add("inf", "Inf")
+	data.Add("negativeInf", "-Inf")
+	val := data.Validator()
+	val.FloatRange("min", 0, 5)
+	val.FloatRange("max", 0, 5)
+	val.FloatRange("middle", 0, 5)
+	val.FloatRange("missing", 0, 5)
+	if val.HasErrors() {
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
+	}
+
+	val.FloatRange("below", 0, 5)
+	val.FloatRange("above", 0, 5)
+	if len(val.Messages()) != 2 {
+		t.Errorf("Expected 2 validation errors but got %d.", len(val.Messages()))
+	}
+
+	val = data.Validator()
+	val.FloatRange("word", 0, 5)
+	val.FloatRange("above", 0, 5)
+	if len(val.Messages()) != 2 {
+		t.Fatalf("Expected 2 validation errors but got %d.", len(val.Messages()))
+	}
+	if typeMsg, rangeMsg := val.Messages()[0], val.Messages()[1]; typeMsg == rangeMsg {
+		t.Errorf("Expected distinct messages for type and range errors but both were: %s", typeMsg)
+	} else if !strings.Contains(typeMsg, "number") {
+		t.Errorf("Expected type error to mention number but got: %s", typeMsg)
+	} else if !strings.Contains(rangeMsg, "between 0 and 5") {
+		t.Errorf("Expected range error to mention the bounds but got: %s", rangeMsg)
+	}
+
+	// NaN and infinite values are type errors, even with unbounded ranges
+	val = data.Validator()
+	val.FloatRange("nan", math.Inf(-1), math.Inf(1))
+	val.FloatRange("inf", math.Inf(-1), math.Inf(1))
+	val.FloatRange("negativeInf", math.Inf(-1), math.Inf(1))
+	if len(val.Messages()) != 3 {
+		t.Fatalf("Expected 3 validation errors but got %d.", len(val.Messages()))
+	}
+	for _, msg := range val.Messages() {
+		if !strings.Contains(msg, "number") {
+			t.Errorf("Expected type error to mention number but got: %s", msg)
+		}
+	}
+}
+
+func TestDateRange(t *testing.T) {
+	data := newData()
+	data.Add("first", "2015-06-01")
+	data.Add("last", "2015-06-30")
+	data.Add("early", "2015-05-31")
+	data.Add("late", "2015-07-01")
+	data.Add("malformed", "June 1st")
+	layout := "2006-01-02"
+	min := time.Date(2015, 6, 1, 0, 0, 0, 0, time.UTC)
+	max := time.Date(2015, 6, 30, 0, 0, 0, 0, time.UTC)
+
+	val := data.Validator()
+	val.DateRange("first", layout, min, max)
+	val.DateRange("last", layout, min, max)
+	val.DateRange("missing", layout, min, max)
+	if val.HasErrors() {
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
+	}
+
+	val.DateRange("early", layout, min, max)
+	val.DateRange("late", layout, min, max)
+	val.DateRange("malformed", layout, min, max)
+	msgs := val.Messages()
+	if len(msgs) != 3 {
+		t.Fatalf("Expected 3 validation errors but got %d.", len(msgs))
+	}
+	if expected := "early must be between 2015-06-01 and 2015-06-30."; msgs[0] != expected {
+		t.Errorf(`Expected message "%s" but got "%s"`, expected, msgs[0])
+	}
+	if msgs[2] == msgs[0] {
+		t.Errorf("Expected a distinct message for a malformed date but got: %s", msgs[2])
+	}
+}
+
+func TestSubset(t *testing.T) {
+	allowed := []string{"red", "green", "blue"}
+	data := newData()
+	data.Values["valid"] = []string{"red", "blue"}
+	data.Values["invalid"] = []string{"purple", "orange"}
+	data.Values["tooFew"] = []string{"green"}
+	data.Values["tooMany"] = []string{"red", "green", "blue"}
+	data.Values["both"] = []string{"purple"}
+	val := data.Validator()
+	val.Subset("valid", allowed, 1, 2)
+	val.Subset("missing", allowed, 0, 2)
+	if val.HasErrors() {
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
+	}
+
+	table := []struct {
+		field    string
+		min      int
+		expected int
+	}{
+		{field: "invalid", min: 1, expected: 1},
+		{field: "tooFew", min: 2, expected: 1},
+		{field: "tooMany", min: 1, expected: 1},
+		{field: "missing", min: 1, expected: 1},
+		{field: "both", min: 2, expected: 2},
+	}
+	for _, test := range table {
+		val := data.Validator()
+		result := val.Subset(test.field, allowed, test.min, 2)
+		if got := len(val.Messages()); got != test.expected {
+			t.Errorf("%s: expected %d errors but got %d: %v", test.field, test.expected, got, val.Messages())
+		}
+		if result.Ok {
+			t.Errorf("%s: expected the result not to be ok", test.field)
+		}
+	}
+}
+
+func TestUnique(t *testing.T) {
+	data := newData()
+	data.Values["unique"] = []string{"a", "b"}
+	data.Values["single"] = []string{"a"}
+	data.Values["duplicates"] = []string{"a", "b", "a"}
+	data.Values["mixedCase"] = []string{"Go", "go"}
+	// "K" is equal to the Kelvin sign under strings.EqualFold, but not
+	// under strings.ToLower
+	data.Values["kelvin"] = []string{"K", "\u212a"}
+	data.Values["sigma"] = []string{"\u03a3", "\u03c2"}
+	val := data.Validator()
+	val.Unique("unique")
+	val.Unique("single")
+	val.Unique("missing")
+	val.Unique("mixedCase")
+	val.UniqueFold("unique")
+	if val.HasErrors() {
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
+	}
+
+	val.Unique("duplicates")
+	val.UniqueFold("mixedCase")
+	val.UniqueFold("kelvin")
+	val.UniqueFold("sigma")
+	if fields := val.Fields(); !reflect.DeepEqual(fields, []string{"duplicates", "mixedCase", "kelvin", "sigma"}) {
+		t.Fatalf("Expected errors on duplicates, mixedCase, kelvin, and sigma but got errors on %v", fields)
+	}
+	if msg := val.Messages()[0]; !strings.Contains(msg, `"a"`) {
+		t.Errorf("Expected error to name the duplicate value but got: %s", msg)
+	}
+}
+
+func TestCount(t *testing.T) {
+	data := newData()
+	data.Add("toppings", "cheese")
+	data.Add("toppings", "olives")
+	val := data.Validator()
+	val.CountBetween("toppings", 1, 3)
+	val.CountBetween("toppings", 2, 2)
+	val.CountBetween("missing", 0, 1)
+	val.CountExactly("toppings", 2)
+	val.CountAtLeast("toppings", 2)
+	if val.HasErrors() {
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
+	}
+
+	val.CountBetween("toppings", 3, 5)
+	val.CountBetween("toppings", 0, 1)
+	val.CountBetween("missing", 1, 3)
+	val.CountExactly("toppings", 3)
+	val.CountAtLeast("toppings", 3)
+	if len(val.Messages()) != 5 {
+		t.Errorf("Expected 5 validation errors but got %d.", len(val.Messages()))
+	}
+}
+
+func TestPassword(t *testing.T) {
+	data := newData()
+	data.Add("strong", "Tr0ub4dor&3")
+	data.Add("weak", "password")
+	data.Add("empty", "")
+	opts := PasswordOpts{
+		MinLength:     10,
+		RequireUpper:  true,
+		RequireLower:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+	}
+
+	val := data.Validator()
+	val.Password("strong", opts)
+	val.Password("missing", opts)
+	if val.HasErrors() {
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
+	}
+
+	val.Password("weak", opts)
+	expected := []string{
+		"weak must be at least 10 characters long.",
+		"weak must contain an uppercase letter.",
+		"weak must contain a digit.",
+		"weak must contain a symbol.",
+	}
+	if got := val.Messages(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected messages %v but got %v", expected, got)
+	}
+
+	val = data.Validator()
+	val.Password("empty", opts)
+	if len(val.Messages()) != 5 {
+		t.Errorf("Expected 5 validation errors for an empty password but got %d.", len(val.Messages()))
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	data := newData()
+	data.Add("phone", "(555) 123-4567")
+	data.Add("phone", "(555) 765-4321")
+	data.Add("other", "(555) 123-4567")
+	data.Add("type", "personal")
+	digitsOnly := func(value string) string {
+		return regexp.MustCompile(`\D`).ReplaceAllString(value, "")
+	}
+	val := data.Validator()
+	val.Sanitize("phone", digitsOnly)
+	val.Sanitize("missing", digitsOnly)
+	val.When("type", "business").Sanitize("other", digitsOnly)
+	val.MinLength("phone", 10)
+	if val.HasErrors() {
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
+	}
+	if got := data.Get("phone"); got != "5551234567" {
+		t.Errorf(`Expected phone to be "5551234567" but got "%s"`, got)
+	}
+	if got := data.Values["phone"][1]; got != "(555) 765-4321" {
+		t.Errorf(`Expected second phone to be unchanged but got "%s"`, got)
+	}
+	if got := data.Get("other"); got != "(555) 123-4567" {
+		t.Errorf(`Expected other to be unchanged when the condition is not met but got "%s"`, got)
+	}
+	if data.KeyExists("missing") {
+		t.Error("Expected Sanitize not to add a missing key.")
+	}
+}
+
+func TestLuhn(t *testing.T) {
+	data := newData()
+	data.Add("plain", "4111111111111111")
+	data.Add("spaced", "4111 1111 1111 1111")
+	data.Add("dashed", "4012-8888-8888-1881")
+	data.Add("offByOne", "4111111111111112")
+	data.Add("letters", "4111a11111111111")
+	data.Add("empty", "")
+	val := data.Validator()
+	val.Luhn("plain")
+	val.Luhn("spaced")
+	val.Luhn("dashed")
+	val.Luhn("missing")
+	if val.HasErrors() {
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
+	}
+
+	val.Luhn("offByOne")
+	val.Luhn("letters")
+	val.Luhn("empty")
+	if fields := val.Fields(); !reflect.DeepEqual(fields, []string{"offByOne", "letters", "empty"}) {
+		t.Errorf("Expected errors on offByOne, letters, and empty but got errors on %v", fields)
+	}
+}
+
+func TestPhone(t *testing.T) {
+	for _, value := range []string{"+14155550123", "(415) 555-0123", "415.555.0123", "555 0123"} {
+		val := CreateFromMap(map[string]string{"phone": value}).Validator()
+		val.Phone("phone")
+		if val.HasErrors() {
+			t.Errorf("Expected %q to be valid but got errors: %v", value, val.Messages())
+		}
+	}
+	for _, value := range []string{"12", "abc", "", "+1 415 555 0123 45678", "415-555-0123 ext 5", "++14155550123"} {
+		val := CreateFromMap(map[string]string{"phone": value}).Validator()
+		val.Phone("phone")
+		if !val.HasErrors() {
+			t.Errorf("Expected %q to be invalid but got no errors", value)
+		}
+	}
+	val := newData().Validator()
+	val.Phone("missing")
+	if val.HasErrors() {
+		t.Errorf("Expected missing field to be skipped but got errors: %v", val.Messages())
+	}
+}
+
+func TestCustom(t *testing.T) {
+	data := newData()
+	data.Add("even", "42")
+	data.Add("odd", "7")
+	isEven := func(value string) (bool, string) {
+		n, err := strconv.Atoi(value)
+		if err != nil || n%2 != 0 {
+			return false, "must be an even number."
+		}
+		return true, ""
+	}
+	val := data.Validator()
+	val.Custom("even", isEven)
+	if val.HasErrors() {
+		t.Errorf("Expected no errors but got errors: %v", val.Messages())
+	}
+
+	val.Custom("odd", isEven)
+	if len(val.Messages()) != 1 {
+		t.Errorf("Expected 1 validation error but got %d.", len(val.Messages()))
+	} else if got := val.ErrorMap()["odd"][0]; got != "must be an even number." {
+		t.Errorf(`Expected custom message "must be an even number." but got "%s"`, got)
+	}
+
+	// fn should be called with an empty string for a missing field
+	called := false
+	val.Custom("missing", func(value string) (bool, string) {
+		called = true
+		if value != "" {
+			t.Errorf(`Expected value to be "" but got "%s"`, value)
+		}
+		return true, ""
+	})
+	if !called {
+		t.Error("Expected fn to be called for a missing field but it was not.")
+	}
+}
+
 func TestAcceptFileExts(t *testing.T) {
 	data := newData()
 	fileHeader, err := createTestFileHeader("test_file.txt", []byte{})